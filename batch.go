@@ -0,0 +1,108 @@
+package mcts
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// Leaf is a leaf of the search structure pending evaluation by a BatchEvaluator.
+type Leaf[T Counter] struct {
+	// State is the state of the leaf from SearchInterface.Snapshot.
+	State any
+
+	// Actions are the Actions of the children added when the leaf was expanded
+	// with their normalized prior weights.
+	//
+	// The BatchEvaluator may replace the Weight of each Action with a new prior weight.
+	// Weights are renormalized so they only need to be proportional.
+	// Actions is nil when the leaf has no new children, such as at chance nodes.
+	Actions []FrontierAction
+
+	// Counters and NumRollouts are set by the BatchEvaluator to the value estimate
+	// of the leaf and are backpropagated as with RolloutInterface.Evaluate.
+	Counters    T
+	NumRollouts float64
+}
+
+// BatchEvaluator evaluates leaves of the search structure in batches,
+// such as with a value and policy network served by another process.
+type BatchEvaluator[T Counter] interface {
+	// EvaluateBatch sets the Counters and NumRollouts of each leaf and optionally
+	// the prior weights of its Actions.
+	//
+	// EvaluateBatch is called without holding the search structure.
+	// With Search.NumWorkers > 1, EvaluateBatch is called concurrently by each worker.
+	EvaluateBatch(leaves []Leaf[T])
+}
+
+// batch holds the SearchInterface of each leaf in a batch.
+//
+// The slots share the search state of a worker but each keeps its own
+// traversal state so that the leaves can be backpropagated after evaluation.
+type batch[T Counter] struct {
+	slots   []SearchInterface[T]
+	leaves  []Leaf[T]
+	pending []int // Index of the slot of each leaf.
+}
+
+// newBatch returns a batch of BatchSize slots for the worker using si.
+func (s *Search[T]) newBatch(si SearchInterface[T]) *batch[T] {
+	if si.InternalInterface.Fork == nil {
+		panic("Search.Search: Search.InternalInterface.Fork is nil. Fork is required with BatchEvaluator.")
+	}
+	b := &batch[T]{slots: make([]SearchInterface[T], s.BatchSize)}
+	for i := range b.slots {
+		b.slots[i] = si
+		b.slots[i].InternalInterface = si.InternalInterface.Fork(&b.slots[i])
+	}
+	return b
+}
+
+// batchLen returns the number of episodes in the next batch after n of maxEpisodes completed episodes.
+func (s *Search[T]) batchLen(n, maxEpisodes int) int {
+	if maxEpisodes >= 0 && s.BatchSize > maxEpisodes-n {
+		return maxEpisodes - n
+	}
+	return s.BatchSize
+}
+
+// searchBatch runs k episodes using the slots of b and evaluates the leaves
+// in a single call to EvaluateBatch.
+//
+// If mu is not nil, it is held for all operations on the search structure
+// and released during evaluation.
+func (s *Search[T]) searchBatch(b *batch[T], k int, r *rand.Rand, mu *sync.Mutex) {
+	b.leaves, b.pending = b.leaves[:0], b.pending[:0]
+	if mu != nil {
+		mu.Lock()
+	}
+	for i, si := range b.slots[:k] {
+		s.selectLeaf(si, r)
+		leaf, pending := si.Leaf(si)
+		if !pending {
+			// Terminal and proven leaves are scored immediately.
+			si.Backprop(s.CounterInterface, leaf.Counters, leaf.NumRollouts, s.ExploreFactor)
+			continue
+		}
+		leaf.State = si.Snapshot()
+		b.leaves = append(b.leaves, leaf)
+		b.pending = append(b.pending, i)
+	}
+	if mu != nil {
+		mu.Unlock()
+	}
+	if len(b.leaves) == 0 {
+		return
+	}
+	s.BatchEvaluator.EvaluateBatch(b.leaves)
+	if mu != nil {
+		mu.Lock()
+		defer mu.Unlock()
+	}
+	for j, i := range b.pending {
+		si, leaf := b.slots[i], b.leaves[j]
+		si.SetPriors(leaf.Actions)
+		// Backprop is always called to release in-flight edges.
+		si.Backprop(s.CounterInterface, leaf.Counters, leaf.NumRollouts, s.ExploreFactor)
+	}
+}
@@ -12,6 +12,49 @@ type InternalInterface[T Counter] struct {
 	Backprop    func(counter CounterInterface[T], counters T, numRollouts, exploreFactor float64)
 	Rollout     func(s SearchInterface[T], ri RolloutInterface[T], r *rand.Rand) (counters T, numRollouts float64)
 	Expand      func(s SearchInterface[T], r *rand.Rand) (hasChild bool)
-	SelectChild func(s SearchInterface[T]) (hasChild, expand bool)
+	SelectChild func(s SearchInterface[T], r *rand.Rand) (hasChild, expand bool)
 	MakeNode    func(action FrontierAction) Node[T]
+
+	// Fork returns an InternalInterface which shares the search structure but keeps
+	// separate traversal state for use by a concurrent worker.
+	//
+	// Fork may patch s with per-worker implementations such as the default Hash.
+	// Fork is required when Search.NumWorkers > 1.
+	Fork func(s *SearchInterface[T]) InternalInterface[T]
+
+	// NumNodes returns the number of Nodes in the search structure.
+	NumNodes func() int
+
+	// AdvanceRoot re-roots the search structure at the state reached by applying actions.
+	// See Search.AdvanceRoot.
+	AdvanceRoot func(s *Search[T], actions []Action) bool
+
+	// RootComplete returns true if every Action at the root is in the search structure.
+	// Early stopping is skipped while the root is not complete.
+	// See Search.EarlyStopInterval.
+	RootComplete func() bool
+
+	// Solved returns true if the best Action at the root is proven.
+	// See Search.Solver.
+	Solved func() bool
+
+	// Prune removes Nodes from the search structure until at most maxNodes remain
+	// and returns the number of Nodes removed.
+	// See Search.NodeBudget.
+	Prune func(maxNodes int) int
+
+	// JointStats returns the statistics of each player at the simultaneous move node n
+	// or nil if n is not a simultaneous move node.
+	// See Search.JointStats.
+	JointStats func(n *EdgeList[T]) []PlayerStats[T]
+
+	// Leaf returns the leaf reached by the episode for evaluation by a BatchEvaluator.
+	// Leaf returns false for terminal and proven leaves along with their score,
+	// which are not evaluated.
+	// See Search.BatchEvaluator.
+	Leaf func(s SearchInterface[T]) (leaf Leaf[T], pending bool)
+
+	// SetPriors sets the prior weights of the children of the last pending leaf
+	// from the Actions of its evaluated Leaf.
+	SetPriors func(actions []FrontierAction)
 }
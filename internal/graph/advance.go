@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"github.com/wenooij/mcts"
+	"github.com/wenooij/mcts/searchops"
+)
+
+// advanceRoot re-roots the search structure at the EdgeList reached by following actions from the root.
+//
+// Unreachable EdgeLists are dropped from Table and InverseTable.
+// When the Edges are valued against a root player who no longer acts at the new root,
+// the search structure is reset instead.
+// advanceRoot returns false if the new root was not in the search structure or was reset.
+//
+// precondition: s.Root resets to the new root state.
+func (g *graphInterface[T]) advanceRoot(s *mcts.Search[T], actions []mcts.Action) bool {
+	g.ForwardPath = g.ForwardPath[:0]
+	var (
+		e  = g.RootEdge
+		n  = g.RootEdge.Dst
+		h  uint64
+		ok = true
+	)
+	if g.InverseTable != nil {
+		h = g.InverseTable[n]
+	}
+	for _, a := range actions {
+		if g.InverseTable != nil {
+			// Keep the default hash chain even when the subtree is missing.
+			h = g.hashChild(h, a)
+		}
+		if !ok {
+			continue
+		}
+		if e = searchops.Child(n, a); e == nil || e.Dst == nil {
+			ok = false
+			continue
+		}
+		n = e.Dst
+	}
+	s.Root()
+	if g.rootPlayer >= 0 && s.Player() != g.rootPlayer {
+		// Statistics such as SquaredScore and Proof were kept under the old
+		// objectives and cannot be revalued.
+		g.reset(s)
+		s.RootEntry = nil
+		return false
+	}
+	if !ok {
+		n = g.lookupRoot(s.SearchInterface, h)
+		e = nil
+	}
+	root := newRootEdge(n)
+	if e != nil {
+		// Copy the score so that the root does not alias the counters of e.
+		root.Score.Objective = s.Score().Objective
+		s.CounterInterface.Add(&root.Score.Counter, e.Score.Counter)
+		root.NumRollouts = e.NumRollouts
+		root.SquaredScore = e.SquaredScore
+		root.Proof, root.ProofDepth, root.ProofCounter = e.Proof, e.ProofDepth, e.ProofCounter
+		root.Expanded = e.Expanded
+	} else {
+		initializeScore(s.SearchInterface, root)
+	}
+	g.RootEdge = root
+	g.dropUnreachable()
+	s.RootEntry = g.RootEdge.Dst
+	return ok
+}
+
+// lookupRoot finds or creates the root EdgeList for the current state.
+//
+// h is the default hash of the root and is only used with the default Hash implementation.
+func (g *graphInterface[T]) lookupRoot(s mcts.SearchInterface[T], h uint64) *mcts.EdgeList[T] {
+	if g.tree() {
+		return g.arena.newEdgeList()
+	}
+	if g.InverseTable == nil {
+		h = s.Hash()
+	}
+	n, ok := g.Table[h]
+	if !ok {
+		n = g.arena.newEdgeList()
+		g.Table[h] = n
+		if g.InverseTable != nil {
+			g.InverseTable[n] = h
+		}
+	}
+	return n
+}
@@ -0,0 +1,136 @@
+package graph
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+// countNodes counts the Edges and EdgeLists reachable from root.
+func countNodes[T mcts.Counter](root *mcts.EdgeList[T]) (numNodes, numLists int) {
+	visited := map[*mcts.EdgeList[T]]bool{}
+	var walk func(n *mcts.EdgeList[T])
+	walk = func(n *mcts.EdgeList[T]) {
+		if n == nil || visited[n] {
+			return
+		}
+		visited[n] = true
+		numNodes += len(*n)
+		for _, e := range *n {
+			walk(e.Dst)
+		}
+	}
+	walk(root)
+	return numNodes, len(visited)
+}
+
+func TestAdvanceRoot(t *testing.T) {
+	for _, topo := range []mcts.Topo{mcts.TopoDefault, mcts.TopoGraph} {
+		r := rand.New(rand.NewSource(1337))
+		d := &dummySearch{Topo: topo, BranchFactor: 3, MaxDepth: 6, Rand: r}
+		s := mcts.Search[float64]{SearchInterface: d.Interface(), Rand: r, NumEpisodes: 300}
+		s.Search()
+
+		best := (*s.RootEntry)[0]
+		for _, e := range *s.RootEntry {
+			if e.NumRollouts > best.NumRollouts {
+				best = e
+			}
+		}
+		wantRoot, wantRollouts := best.Dst, best.NumRollouts
+		wantNodes, _ := countNodes(wantRoot)
+		wantChildren := make(map[string]float64, len(*wantRoot))
+		for _, e := range *wantRoot {
+			wantChildren[e.Action.String()] = e.NumRollouts
+		}
+
+		d.RootDepth = 1
+		if !s.AdvanceRoot(best.Action) {
+			t.Fatalf("TestAdvanceRoot(%d): AdvanceRoot(%s) returned false", topo, best.Action)
+		}
+		// The subtree may be moved but keeps its children and statistics.
+		if len(*s.RootEntry) != len(wantChildren) {
+			t.Fatalf("TestAdvanceRoot(%d): got %d root children, want the %d children of the existing subtree", topo, len(*s.RootEntry), len(wantChildren))
+		}
+		for _, e := range *s.RootEntry {
+			if want, ok := wantChildren[e.Action.String()]; !ok || e.NumRollouts != want {
+				t.Errorf("TestAdvanceRoot(%d): got child %v with %f rollouts, want %f", topo, e.Action, e.NumRollouts, want)
+			}
+		}
+		if got := s.InternalInterface.NumNodes(); got != wantNodes {
+			t.Errorf("TestAdvanceRoot(%d): got NumNodes = %d, want %d", topo, got, wantNodes)
+		}
+		s.NumEpisodes = 100
+		s.Search()
+		var numRollouts float64
+		for _, e := range *s.RootEntry {
+			numRollouts += e.NumRollouts
+		}
+		// Terminal rollouts from the root may not reach a child.
+		if numRollouts < wantRollouts+99 || numRollouts > wantRollouts+100 {
+			t.Errorf("TestAdvanceRoot(%d): got root rollouts = %f, want about %f", topo, numRollouts, wantRollouts+100)
+		}
+	}
+}
+
+func TestAdvanceRootDefaultHash(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	d := &dummySearch{Topo: mcts.TopoGraph, BranchFactor: 3, MaxDepth: 6, Rand: r}
+	g := &graphInterface[float64]{graphState: &graphState[float64]{Topo: mcts.TopoGraph}}
+	si := d.Interface()
+	si.Hash = nil
+	si.InternalInterface = g.InternalInterface()
+	s := mcts.Search[float64]{SearchInterface: si, Rand: r, NumEpisodes: 300}
+	s.Search()
+
+	a := (*s.RootEntry)[0].Action
+	d.RootDepth = 1
+	if !s.AdvanceRoot(a) {
+		t.Fatalf("TestAdvanceRootDefaultHash(): AdvanceRoot(%s) returned false", a)
+	}
+	s.Search()
+
+	_, numLists := countNodes(s.RootEntry)
+	if len(g.Table) != numLists || len(g.InverseTable) != numLists {
+		t.Errorf("TestAdvanceRootDefaultHash(): got |Table| = %d, |InverseTable| = %d, want %d reachable", len(g.Table), len(g.InverseTable), numLists)
+	}
+	for h, n := range g.Table {
+		if g.InverseTable[n] != h {
+			t.Fatalf("TestAdvanceRootDefaultHash(): Table and InverseTable are inconsistent")
+		}
+		for _, e := range *n {
+			if e.Dst == nil {
+				continue
+			}
+			if got, want := g.InverseTable[e.Dst], g.hashChild(h, e.Action); got != want {
+				t.Errorf("TestAdvanceRootDefaultHash(%s): got hash %d, want %d", e.Action, got, want)
+			}
+		}
+	}
+}
+
+func TestAdvanceRootMemory(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	r := rand.New(rand.NewSource(1337))
+	d := &dummySearch{BranchFactor: 20, MaxDepth: 60, Rand: r}
+	s := mcts.Search[float64]{SearchInterface: d.Interface(), Rand: r, NumEpisodes: 1000}
+	for range 20 {
+		s.Search()
+		d.RootDepth++
+		s.AdvanceRoot(mostVisited(*s.RootEntry).Action)
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(&s)
+
+	// Edges surviving AdvanceRoot must not keep the slabs of dropped Edges alive
+	// so the heap stays near the size of the remaining tree.
+	// Without moving the tree to fresh slabs, this grew by over 70 MB.
+	if got := int64(after.HeapAlloc) - int64(before.HeapAlloc); got > 16<<20 {
+		t.Errorf("TestAdvanceRootMemory(): got heap growth of %d bytes, want at most %d", got, 16<<20)
+	}
+}
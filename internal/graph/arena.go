@@ -0,0 +1,111 @@
+package graph
+
+import "github.com/wenooij/mcts"
+
+// slabSize is the minimum number of elements in a slab.
+const slabSize = 4096
+
+// slab allocates elements of E in contiguous blocks to reduce allocations.
+//
+// Slabs are kept for reuse after reset.
+type slab[E any] struct {
+	cur  []E   // Remaining elements in the current slab.
+	used [][]E // Slabs allocated since the last reset.
+	free [][]E // Cleared slabs available for reuse.
+}
+
+// alloc returns a slice of n zero elements with capacity n.
+func (s *slab[E]) alloc(n int) []E {
+	if len(s.cur) < n {
+		s.grow(n)
+	}
+	b := s.cur[:n:n]
+	s.cur = s.cur[n:]
+	return b
+}
+
+func (s *slab[E]) grow(n int) {
+	for i, b := range s.free {
+		if len(b) >= n {
+			s.free = append(s.free[:i], s.free[i+1:]...)
+			s.cur = b
+			s.used = append(s.used, b)
+			return
+		}
+	}
+	s.cur = make([]E, max(n, slabSize))
+	s.used = append(s.used, s.cur)
+}
+
+// reset clears all slabs and keeps them for reuse.
+//
+// Elements previously returned by alloc must no longer be used.
+func (s *slab[E]) reset() {
+	for _, b := range s.used {
+		clear(b)
+	}
+	s.free = append(s.free, s.used...)
+	s.used = nil
+	s.cur = nil
+}
+
+// release drops references to all slabs so unreachable elements can be collected.
+func (s *slab[E]) release() {
+	s.cur = nil
+	s.used = nil
+	s.free = nil
+}
+
+// arena allocates Edges and EdgeLists for the search structure.
+//
+// When bounded is set, Edges and EdgeLists are allocated individually rather than from slabs.
+// A surviving Edge would otherwise keep its whole slab alive after pruning.
+type arena[T mcts.Counter] struct {
+	bounded bool
+
+	edges slab[mcts.Edge[T]]
+	ptrs  slab[*mcts.Edge[T]]
+	lists slab[mcts.EdgeList[T]]
+}
+
+// newEdges returns n zero Edges.
+func (a *arena[T]) newEdges(n int) []mcts.Edge[T] {
+	if a.bounded {
+		return make([]mcts.Edge[T], n)
+	}
+	return a.edges.alloc(n)
+}
+
+// newEdgeList returns an empty EdgeList.
+func (a *arena[T]) newEdgeList() *mcts.EdgeList[T] {
+	if a.bounded {
+		return new(mcts.EdgeList[T])
+	}
+	return &a.lists.alloc(1)[0]
+}
+
+// grow grows the capacity of n to fit k more Edges.
+func (a *arena[T]) grow(n *mcts.EdgeList[T], k int) {
+	if cap(*n)-len(*n) >= k {
+		return
+	}
+	var b []*mcts.Edge[T]
+	if a.bounded {
+		b = make([]*mcts.Edge[T], 0, len(*n)+k)
+	} else {
+		b = a.ptrs.alloc(len(*n) + k)[:0]
+	}
+	*n = append(b, *n...)
+}
+
+func (a *arena[T]) reset() {
+	a.edges.reset()
+	a.ptrs.reset()
+	a.lists.reset()
+}
+
+func (a *arena[T]) release() {
+	a.edges.release()
+	a.ptrs.release()
+	a.lists.release()
+}
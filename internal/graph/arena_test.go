@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+func TestSlabReset(t *testing.T) {
+	var s slab[int]
+	b := s.alloc(10)
+	for i := range b {
+		b[i] = i + 1
+	}
+	s.reset()
+	c := s.alloc(10)
+	if &b[0] != &c[0] {
+		t.Errorf("TestSlabReset(): got a new slab after reset, want the slab reused")
+	}
+	for i, v := range c {
+		if v != 0 {
+			t.Fatalf("TestSlabReset(): got c[%d] = %d after reset, want 0", i, v)
+		}
+	}
+}
+
+func TestSearchAfterReset(t *testing.T) {
+	for _, topo := range []mcts.Topo{mcts.TopoDefault, mcts.TopoGraph} {
+		r := rand.New(rand.NewSource(1337))
+		s := mcts.Search[float64]{
+			SearchInterface: (&dummySearch{Topo: topo, BranchFactor: 10, MaxDepth: 5, Rand: r}).Interface(),
+			Rand:            r,
+			NumEpisodes:     500,
+		}
+		s.Search()
+		s.Reset()
+		s.Rand = r
+		s.Search()
+
+		numNodes, _ := countNodes(s.RootEntry)
+		if got := s.InternalInterface.NumNodes(); got != numNodes {
+			t.Errorf("TestSearchAfterReset(%d): got NumNodes = %d, want %d", topo, got, numNodes)
+		}
+		var numRollouts float64
+		for _, e := range *s.RootEntry {
+			numRollouts += e.NumRollouts
+		}
+		if numRollouts != 500 {
+			t.Errorf("TestSearchAfterReset(%d): got root rollouts = %f, want 500", topo, numRollouts)
+		}
+	}
+}
+
+// expandArena adds an EdgeList of branchFactor Edges as in expand.
+func expandArena(a *arena[float64], branchFactor int) *mcts.EdgeList[float64] {
+	n := a.newEdgeList()
+	a.grow(n, branchFactor)
+	edges := a.newEdges(branchFactor)
+	for i := range edges {
+		edges[i].Src = n
+		*n = append(*n, &edges[i])
+	}
+	return n
+}
+
+// expandHeap adds an EdgeList of branchFactor Edges allocating each Edge separately.
+func expandHeap(branchFactor int) *mcts.EdgeList[float64] {
+	n := new(mcts.EdgeList[float64])
+	*n = slices.Grow(*n, branchFactor)
+	for range branchFactor {
+		*n = append(*n, &mcts.Edge[float64]{Src: n})
+	}
+	return n
+}
+
+// BenchmarkArena measures the allocation of 100 expanded nodes between resets.
+//
+// The heap variant allocates each Edge separately and bounded allocates each node
+// separately as with NodeBudget.
+func BenchmarkArena(b *testing.B) {
+	const numExpand = 100
+	var sink *mcts.EdgeList[float64]
+	for _, branchFactor := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("heap/%d", branchFactor), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for range numExpand {
+					sink = expandHeap(branchFactor)
+				}
+			}
+		})
+		for _, bounded := range []bool{false, true} {
+			name := "arena"
+			if bounded {
+				name = "bounded"
+			}
+			b.Run(fmt.Sprintf("%s/%d", name, branchFactor), func(b *testing.B) {
+				a := arena[float64]{bounded: bounded}
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					for range numExpand {
+						sink = expandArena(&a, branchFactor)
+					}
+					a.reset()
+				}
+			})
+		}
+	}
+	runtime.KeepAlive(sink)
+}
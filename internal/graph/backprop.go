@@ -5,47 +5,345 @@ import (
 
 	"github.com/wenooij/mcts"
 	"github.com/wenooij/mcts/internal/heap"
-	"github.com/wenooij/mcts/internal/model"
 )
 
 func (g *graphInterface[T]) backprop(counter mcts.CounterInterface[T], counters T, numRollouts, exploreFactor float64) {
+	if g.amaf && numRollouts != 0 {
+		g.resetPlayed()
+	}
 	for i := len(g.ForwardPath) - 1; i >= 0; i-- {
 		e := g.ForwardPath[i]
-		counter.Add(&e.Score.Counter, counters)
-		e.NumRollouts += numRollouts
-		if i > 0 {
-			prev := g.ForwardPath[i-1]
-			score := e.Score.Objective(e.Score.Counter)
-			exploreTerm := exploreFactor * math.Sqrt(prev.NumRollouts)
-			e.Priority = -model.PUCB(score, e.NumRollouts, e.PriorWeight, exploreTerm)
-			// NOTE(wes):
-			// The index 0 here relies on a Select step which always
-			// the first element. If we wanted to add a select temperature
-			// parameter, we'd need to track the actual index of the edge.
-			heap.Down(*prev.Dst, 0, len(*prev.Dst))
-		}
-		if len(*e.Src) > 0 {
-			updatePrioritiesPUCB(*e.Src, float64(e.NumRollouts), exploreFactor)
-			// updatePrioritiesPUCB is monotomic for elements n[1:].
-			// We can save work by calling Down only on the first element of n.
-			heap.Down(*e.Src, 0, len(*e.Src))
-		}
-	}
-}
-
-func updatePrioritiesPUCB[T mcts.Counter](es []*mcts.Edge[T], numParentRollouts, exploreFactor float64) {
-	exploreTerm := exploreFactor * math.Sqrt(numParentRollouts)
-	for i := range es {
-		if es[i].NumRollouts > 0 {
-			// PUCB is used to compute the priority of a node as a combination of its mean score
-			// and asymptotic priot exploration factor.
-			//
-			// 	PUCT(n) = Score(n) + Prior(n) * ExploreTerm(n).
-			// 	Priority(n) = -PUCT(n).
-			//
-			// The next call to Down will reheapify E.
-			score := es[i].Score.Objective(es[i].Score.Counter)
-			es[i].Priority = -model.PUCB(score, es[i].NumRollouts, es[i].PriorWeight, exploreTerm)
+		if numRollouts != 0 {
+			counter.Add(&e.Score.Counter, counters)
+			e.NumRollouts += numRollouts
+			// Rollout scores are recorded with the objective of e.
+			v := e.Score.Objective(counters) / numRollouts
+			e.SquaredScore += numRollouts * v * v
+			if g.amaf {
+				g.updateAMAF(i, counters, numRollouts)
+			}
+		}
+		if g.virtualLoss != 0 && i > 0 {
+			// Release the virtual loss applied in selectChild.
+			e.NumInflight--
+		}
+		if _, ok := g.joint[e.Dst]; ok {
+			// Players choose independently at e.Dst and are never proven.
+			if n := len(g.jointSteps); n > 0 && g.jointSteps[n-1].depth == i {
+				if numRollouts != 0 {
+					g.updateJoint(e.Dst, g.jointSteps[n-1], counters, numRollouts)
+				}
+				g.jointSteps = g.jointSteps[:n-1]
+			}
+			continue
+		}
+		if isChance(*e.Dst) {
+			// Outcomes are sampled by Probability and are never proven.
+			continue
+		}
+		if len(*e.Dst) > 0 {
+			if g.solver && e.Proof == mcts.Unproven {
+				if c := g.provenChild(e); c != nil {
+					setProof(e, c.ProofCounter, c.ProofDepth+1)
+				}
+			}
+			if g.bounds {
+				updateBounds(e, g.complete(e))
+			}
+			g.updatePriorities(*e.Dst, e.NumRollouts, exploreFactor)
+			// The value of every child may have changed with the parent's rollouts.
+			// Select may also have chosen any child of the heap, and in parallel search
+			// other workers may have reordered it since. Init restores the heap in every case.
+			heap.Init(*e.Dst)
+		}
+	}
+}
+
+// amafKey is an Action played by a player in the episode.
+type amafKey struct {
+	player int
+	action string
+}
+
+// nextPlayer returns the player choosing the next Action of the episode for AMAF updates.
+//
+// The player is found with Player when it is set. Otherwise players alternate at each decision.
+// nextPlayer returns -1 when decision is false, at chance and simultaneous move nodes.
+func (g *graphInterface[T]) nextPlayer(s mcts.SearchInterface[T], decision bool) int {
+	switch {
+	case !decision:
+		return -1
+	case s.Player != nil:
+		return s.Player()
+	default:
+		return g.ply % 2
+	}
+}
+
+// recordPlayer appends the player p of an Action played in the episode to players.
+func (g *graphInterface[T]) recordPlayer(players []int, p int) []int {
+	if p >= 0 {
+		g.ply++
+	}
+	return append(players, p)
+}
+
+// resetPlayed initializes the set of played actions from the rollout trace.
+func (g *graphInterface[T]) resetPlayed() {
+	if g.played == nil {
+		g.played = make(map[amafKey]struct{}, len(g.trace))
+	}
+	clear(g.played)
+	for j, a := range g.trace {
+		g.played[amafKey{g.tracePlayers[j], a.String()}] = struct{}{}
+	}
+}
+
+// updateAMAF updates the AMAF statistics of the children of ForwardPath[i]
+// whose actions were played later in the episode by the player choosing at ForwardPath[i].
+//
+// updateAMAF must be called for each i in descending order.
+func (g *graphInterface[T]) updateAMAF(i int, counters T, numRollouts float64) {
+	es := *g.ForwardPath[i].Dst
+	// players has an entry for each Edge of the ForwardPath after the root.
+	player := -1
+	if i < len(g.players) {
+		player = g.players[i]
+	} else if len(g.tracePlayers) > 0 {
+		player = g.tracePlayers[0]
+	}
+	var objective func(T) float64
+	if i+1 < len(g.ForwardPath) {
+		next := g.ForwardPath[i+1]
+		g.played[amafKey{player, next.Action.String()}] = struct{}{}
+		objective = next.Score.Objective
+	} else {
+		// Siblings share the objective of the state they lead to.
+		for _, e := range es {
+			if e.Score.Objective != nil {
+				objective = e.Score.Objective
+				break
+			}
+		}
+	}
+	if objective == nil {
+		return
+	}
+	score := objective(counters)
+	for _, e := range es {
+		if _, ok := g.played[amafKey{player, e.Action.String()}]; ok {
+			e.AMAFScore += score
+			e.NumAMAFRollouts += numRollouts
+		}
+	}
+}
+
+func (g *graphInterface[T]) updatePriorities(es []*mcts.Edge[T], numParentRollouts, exploreFactor float64) {
+	sib := g.siblings(es)
+	for _, e := range es {
+		// The next call to Init will reheapify es.
+		e.Priority = g.priority(e, numParentRollouts, exploreFactor, sib)
+	}
+}
+
+// siblingStats are computed over all children of an EdgeList and used to compute
+// the priority of each child.
+type siblingStats struct {
+	// maxPessimistic is the max Pessimistic bound with ScoreBounds or -Inf.
+	maxPessimistic float64
+	// urgency is the value of unvisited children with FirstPlayUrgency.
+	urgency float64
+}
+
+// siblings computes the siblingStats of es.
+func (g *graphInterface[T]) siblings(es []*mcts.Edge[T]) siblingStats {
+	sib := siblingStats{maxPessimistic: math.Inf(-1)}
+	if g.bounds {
+		for _, e := range es {
+			sib.maxPessimistic = max(sib.maxPessimistic, e.Pessimistic)
+		}
+	}
+	if g.fpu != nil {
+		// The parent value is the mean score of visited children.
+		var score, numRollouts float64
+		for _, e := range es {
+			if e.NumRollouts > 0 {
+				score += e.Score.Apply()
+				numRollouts += e.NumRollouts
+			}
+		}
+		var mean float64
+		if numRollouts > 0 {
+			mean = score / numRollouts
+		}
+		sib.urgency = g.fpu.Urgency(mean)
+	}
+	return sib
+}
+
+// priority computes the min heap priority of e using the SelectionPolicy.
+//
+//	Priority(n) = -Value(n).
+//
+// Edges which have not been visited yet keep the max priority unless FirstPlayUrgency is set.
+// With Solver, proven wins have the max priority and proven losses have the min priority.
+// With ScoreBounds, Edges whose Optimistic bound is below a sibling's Pessimistic bound have the min priority
+// as do Edges with tight bounds since searching them gives no more information.
+// Proven wins are no longer preferred so that better wins may be found.
+func (g *graphInterface[T]) priority(e *mcts.Edge[T], numParentRollouts, exploreFactor float64, sib siblingStats) float64 {
+	if g.solver {
+		switch {
+		case g.bounds && (e.Optimistic < sib.maxPessimistic || e.Pessimistic == e.Optimistic):
+			return math.Inf(1)
+		case e.Proof == mcts.ProvenWin && !g.bounds:
+			return math.Inf(-1)
+		case e.Proof == mcts.ProvenLoss:
+			return math.Inf(1)
+		}
+	}
+	if e.NumRollouts == 0 && e.NumInflight == 0 && g.fpu == nil {
+		return math.Inf(-1)
+	}
+	return -g.policy.Value(g.stats(e, numParentRollouts, exploreFactor, sib))
+}
+
+// stats returns the SelectionStats of e.
+//
+// In-flight workers count as additional rollouts with a score of -virtualLoss.
+// Unvisited Edges count as a single rollout scoring the first-play urgency.
+// Edges leading to chance nodes score the Probability-weighted mean of their outcomes
+// rather than the sample mean kept in their Score.
+// With Determinize or TopoOpenLoop, the availability count of e replaces numParentRollouts.
+func (g *graphInterface[T]) stats(e *mcts.Edge[T], numParentRollouts, exploreFactor float64, sib siblingStats) mcts.SelectionStats {
+	if g.filterAvailable {
+		numParentRollouts = e.NumAvailable
+	}
+	if e.NumRollouts == 0 && e.NumInflight == 0 {
+		return mcts.SelectionStats{
+			Score:             sib.urgency,
+			SquaredScore:      sib.urgency * sib.urgency,
+			NumRollouts:       1,
+			PriorWeight:       e.PriorWeight,
+			NumParentRollouts: numParentRollouts,
+			ExploreFactor:     exploreFactor,
+			AMAFScore:         e.AMAFScore,
+			NumAMAFRollouts:   e.NumAMAFRollouts,
+		}
+	}
+	stats := mcts.SelectionStats{
+		Score:             e.Score.Objective(e.Score.Counter),
+		SquaredScore:      e.SquaredScore,
+		NumRollouts:       e.NumRollouts,
+		PriorWeight:       e.PriorWeight,
+		NumParentRollouts: numParentRollouts,
+		ExploreFactor:     exploreFactor,
+		AMAFScore:         e.AMAFScore,
+		NumAMAFRollouts:   e.NumAMAFRollouts,
+	}
+	if e.Dst != nil && isChance(*e.Dst) {
+		if score, ok := expectedScore(*e.Dst, e.Score.Objective, e.NumRollouts); ok {
+			stats.Score = score
+		}
+	}
+	if e.NumInflight > 0 {
+		loss := float64(e.NumInflight) * g.virtualLoss
+		stats.NumRollouts += float64(e.NumInflight)
+		stats.Score -= loss
+		stats.SquaredScore += loss * g.virtualLoss
+	}
+	return stats
+}
+
+// setProof proves e with the terminal score counter reached in depth plies after e.
+func setProof[T mcts.Counter](e *mcts.Edge[T], counter T, depth int) {
+	e.Proof = mcts.MakeProof(e.Score.Objective(counter))
+	e.ProofDepth = depth
+	e.ProofCounter = counter
+}
+
+// provenChild returns the child chosen with best play if the outcome of e.Dst is proven.
+//
+// Until e is complete only a proven win is sufficient.
+// With filterAvailable no child proves e.
+func (g *graphInterface[T]) provenChild(e *mcts.Edge[T]) *mcts.Edge[T] {
+	if g.filterAvailable {
+		// A proven child may not be available in every episode.
+		return nil
+	}
+	c := e.Dst.ProvenChild()
+	if c != nil && c.Proof != mcts.ProvenWin && !g.complete(e) {
+		return nil
+	}
+	return c
+}
+
+// rootSolved returns true if the best Action at the root is proven.
+//
+// With ScoreBounds, the best Action is proven when its Pessimistic bound is at least
+// the Optimistic bound of every other Action. Otherwise, it is proven when the outcome
+// of the root is proven.
+func (g *graphInterface[T]) rootSolved() bool {
+	if g.bounds {
+		return g.complete(g.RootEdge) && g.RootEdge.Dst.BoundedChild() != nil
+	}
+	return g.provenChild(g.RootEdge) != nil
+}
+
+// solved returns true if the value of e is known and search below e is no longer needed.
+//
+// With ScoreBounds, a proof is not sufficient unless the bounds of e are also tight.
+func (g *graphInterface[T]) solved(e *mcts.Edge[T]) bool {
+	return g.solver && e.Proof != mcts.Unproven && (!g.bounds || e.Pessimistic == e.Optimistic)
+}
+
+// updateBounds tightens the bounds of e from the bounds of its children.
+//
+// The player choosing among the children maximizes their bounds.
+// The optimistic bound is unknown until e is complete.
+// The bounds are mapped into the objective of e when it is the objective of
+// the children or its negation. Otherwise the bounds of e are unchanged.
+func updateBounds[T mcts.Counter](e *mcts.Edge[T], complete bool) {
+	es := *e.Dst
+	pessimistic, optimistic := math.Inf(-1), math.Inf(-1)
+	for _, c := range es {
+		pessimistic = max(pessimistic, c.Pessimistic)
+		optimistic = max(optimistic, c.Optimistic)
+	}
+	if !complete {
+		optimistic = math.Inf(1)
+	}
+	switch objectiveSign(e, es) {
+	case 1:
+	case -1:
+		pessimistic, optimistic = -optimistic, -pessimistic
+	default:
+		return
+	}
+	e.Pessimistic = max(e.Pessimistic, pessimistic)
+	e.Optimistic = min(e.Optimistic, optimistic)
+}
+
+// objectiveSign returns 1 if the objective of e agrees with the objective of its children,
+// -1 if it is the negation, or 0 if unknown.
+//
+// The sign is found by comparing the objectives on the counters of visited children.
+func objectiveSign[T mcts.Counter](e *mcts.Edge[T], es []*mcts.Edge[T]) int {
+	for _, c := range es {
+		if c.Score.Objective == nil {
+			continue
+		}
+		x := c.Score.Apply()
+		if x == 0 {
+			continue
+		}
+		switch e.Score.Objective(c.Score.Counter) {
+		case x:
+			return 1
+		case -x:
+			return -1
+		default:
+			return 0
 		}
 	}
+	return 0
 }
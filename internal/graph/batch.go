@@ -0,0 +1,49 @@
+package graph
+
+import "github.com/wenooij/mcts"
+
+// leaf returns the leaf at the end of the ForwardPath for a BatchEvaluator.
+//
+// Proven and terminal leaves are not pending and are returned with their score.
+// The children of a newly expanded decision node are recorded for setPriors.
+func (g *graphInterface[T]) leaf(s mcts.SearchInterface[T]) (leaf mcts.Leaf[T], pending bool) {
+	g.trace, g.tracePlayers = g.trace[:0], g.tracePlayers[:0]
+	g.leafEdges = g.leafEdges[:0]
+	if e := g.ForwardPath[len(g.ForwardPath)-1]; g.solved(e) {
+		// Return the proven score.
+		return mcts.Leaf[T]{Counters: e.ProofCounter, NumRollouts: 1}, false
+	}
+	if g.terminal {
+		return mcts.Leaf[T]{Counters: s.Score().Counter, NumRollouts: 1}, false
+	}
+	n := g.node()
+	if _, joint := g.joint[n]; !g.expanded || joint || isChance(*n) {
+		return leaf, true
+	}
+	g.leafEdges = append(g.leafEdges, *n...)
+	leaf.Actions = make([]mcts.FrontierAction, len(*n))
+	for i, e := range *n {
+		leaf.Actions[i] = mcts.FrontierAction{Action: e.Action, Weight: e.PriorWeight}
+	}
+	return leaf, true
+}
+
+// setPriors replaces the prior weights of the children of the last pending leaf
+// with the weights of actions, keeping the total prior weight of the children.
+func (g *graphInterface[T]) setPriors(actions []mcts.FrontierAction) {
+	if len(g.leafEdges) == 0 {
+		return
+	}
+	if len(actions) != len(g.leafEdges) {
+		panic("setPriors: BatchEvaluator changed the number of Actions of a Leaf")
+	}
+	var sumOld, sumNew float64
+	for i, e := range g.leafEdges {
+		sumOld += e.PriorWeight
+		sumNew += priorWeight(actions[i])
+	}
+	// Children may have been reordered by other episodes so Edges are kept by pointer.
+	for i, e := range g.leafEdges {
+		e.PriorWeight = priorWeight(actions[i]) * sumOld / sumNew
+	}
+}
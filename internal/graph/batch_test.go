@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+// walkEvaluator evaluates walkSearch leaves by their position and gives action 0 three times the prior weight of action 1.
+type walkEvaluator struct {
+	MaxDepth int
+
+	mu              sync.Mutex
+	batchSizes      []int
+	numLeaves       int
+	numTerminalEval int
+}
+
+func (e *walkEvaluator) EvaluateBatch(leaves []mcts.Leaf[float64]) {
+	e.mu.Lock()
+	e.batchSizes = append(e.batchSizes, len(leaves))
+	e.numLeaves += len(leaves)
+	e.mu.Unlock()
+	for i := range leaves {
+		state := leaves[i].State.(walkState)
+		if state.depth >= e.MaxDepth {
+			e.mu.Lock()
+			e.numTerminalEval++
+			e.mu.Unlock()
+		}
+		leaves[i].Counters, leaves[i].NumRollouts = float64(state.x)/float64(e.MaxDepth), 1
+		for j, a := range leaves[i].Actions {
+			leaves[i].Actions[j].Weight = 1
+			if a.Action.(banditAction) == 0 {
+				leaves[i].Actions[j].Weight = 3
+			}
+		}
+	}
+}
+
+// checkInflight reports Edges in the tree below es with NumInflight != 0.
+func checkInflight(t *testing.T, es mcts.EdgeList[float64]) {
+	t.Helper()
+	for _, e := range es {
+		if e.NumInflight != 0 {
+			t.Errorf("TestBatch(): got NumInflight = %d for %v, want 0", e.NumInflight, e.Action)
+		}
+		if e.Dst != nil {
+			checkInflight(t, *e.Dst)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &walkSearch{MaxDepth: 1000}
+	e := &walkEvaluator{MaxDepth: 1000}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, BatchEvaluator: e, BatchSize: 16, NumEpisodes: 500}
+	res := s.Search()
+
+	if res.NumEpisodes != 500 {
+		t.Errorf("TestBatch(): got %d episodes, want 500", res.NumEpisodes)
+	}
+	// Terminal states are never reached in the long walk so every leaf is evaluated.
+	if e.numLeaves != 500 {
+		t.Errorf("TestBatch(): got %d evaluated leaves, want 500", e.numLeaves)
+	}
+	for i, n := range e.batchSizes {
+		if want := min(16, 500-16*i); n != want {
+			t.Errorf("TestBatch(): got batch %d of size %d, want %d", i, n, want)
+		}
+	}
+	if g.numEvaluate != 0 || g.numRolloutSteps != 0 {
+		t.Errorf("TestBatch(): got %d calls to Evaluate and %d rollout steps, want 0", g.numEvaluate, g.numRolloutSteps)
+	}
+	if got := mostVisited(*s.RootEntry).Action.(banditAction); got != 0 {
+		t.Errorf("TestBatch(): got best action %v, want 0", got)
+	}
+	for _, c := range *s.RootEntry {
+		want := 0.25
+		if c.Action.(banditAction) == 0 {
+			want = 0.75
+		}
+		if math.Abs(c.PriorWeight-want) > 1e-9 {
+			t.Errorf("TestBatch(): got PriorWeight = %f for %v, want %f", c.PriorWeight, c.Action, want)
+		}
+	}
+	checkInflight(t, *s.RootEntry)
+}
+
+func TestBatchTerminal(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &walkSearch{MaxDepth: 3}
+	e := &walkEvaluator{MaxDepth: 3}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, BatchEvaluator: e, BatchSize: 8, NumEpisodes: 200}
+	s.Search()
+
+	if e.numTerminalEval != 0 {
+		t.Errorf("TestBatchTerminal(): got %d terminal leaves passed to EvaluateBatch, want 0", e.numTerminalEval)
+	}
+	// Terminal leaves are scored without the evaluator once the small tree is expanded.
+	if e.numLeaves >= 200 {
+		t.Errorf("TestBatchTerminal(): got %d evaluated leaves, want fewer than 200", e.numLeaves)
+	}
+	if got := mostVisited(*s.RootEntry).Action.(banditAction); got != 0 {
+		t.Errorf("TestBatchTerminal(): got best action %v, want 0", got)
+	}
+}
+
+func TestBatchParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &walkSearch{MaxDepth: 1000}
+	e := &walkEvaluator{MaxDepth: 1000}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, BatchEvaluator: e, BatchSize: 8, NumEpisodes: 500, NumWorkers: 4}
+	res := s.Search()
+
+	if res.NumEpisodes != 500 || e.numLeaves != 500 {
+		t.Errorf("TestBatchParallel(): got %d episodes and %d evaluated leaves, want 500", res.NumEpisodes, e.numLeaves)
+	}
+	if got := mostVisited(*s.RootEntry).Action.(banditAction); got != 0 {
+		t.Errorf("TestBatchParallel(): got best action %v, want 0", got)
+	}
+	checkInflight(t, *s.RootEntry)
+}
+
+func TestBatchRequiresSnapshot(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("TestBatchRequiresSnapshot(): want panic")
+		}
+	}()
+	si := (&walkSearch{MaxDepth: 10}).Interface()
+	si.Snapshot = nil
+	s := mcts.Search[float64]{SearchInterface: si, BatchEvaluator: &walkEvaluator{MaxDepth: 10}}
+	s.Init()
+}
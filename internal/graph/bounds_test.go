@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+// countdownSearch is a single player game which subtracts 1, 2, or 3 from N
+// and scores minus the number of moves to reach 0.
+type countdownSearch struct {
+	N     int
+	n     int
+	depth int
+}
+
+func (s *countdownSearch) Root() { s.n, s.depth = s.N, 0 }
+func (s *countdownSearch) Select(a mcts.Action) bool {
+	s.n -= int(a.(banditAction))
+	s.depth++
+	return true
+}
+func (s *countdownSearch) Expand(int) []mcts.FrontierAction {
+	var actions []mcts.FrontierAction
+	for n := 1; n <= min(3, s.n); n++ {
+		actions = append(actions, mcts.FrontierAction{Action: banditAction(n)})
+	}
+	return actions
+}
+func (s *countdownSearch) Score() mcts.Score[float64] {
+	var x float64
+	if s.n == 0 {
+		x = -float64(s.depth)
+	}
+	return mcts.Score[float64]{Counter: x, Objective: func(x float64) float64 { return x }}
+}
+func (s *countdownSearch) Interface() mcts.SearchInterface[float64] {
+	return SearchInterface(mcts.SearchInterface[float64]{
+		Root:   s.Root,
+		Select: s.Select,
+		Expand: s.Expand,
+		Score:  s.Score,
+	})
+}
+
+func TestScoreBoundsSinglePlayer(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	c := &countdownSearch{N: 6}
+	s := mcts.Search[float64]{SearchInterface: c.Interface(), Rand: r, ScoreBounds: true, NumEpisodes: 100000}
+	if res := s.Search(); res.StopReason != mcts.StopProven {
+		t.Fatalf("TestScoreBoundsSinglePlayer(): got StopReason = %v, want %v", res.StopReason, mcts.StopProven)
+	}
+	e := s.RootEntry.BoundedChild()
+	if e == nil {
+		t.Fatalf("TestScoreBoundsSinglePlayer(): got BoundedChild = nil")
+	}
+	if got := int(e.Action.(banditAction)); got != 3 {
+		t.Errorf("TestScoreBoundsSinglePlayer(): got best action = %d, want 3", got)
+	}
+	if e.Pessimistic != -2 {
+		t.Errorf("TestScoreBoundsSinglePlayer(): got Pessimistic = %f, want -2", e.Pessimistic)
+	}
+}
+
+func TestScoreBoundsTwoPlayer(t *testing.T) {
+	for _, topo := range []mcts.Topo{mcts.TopoDefault, mcts.TopoGraph} {
+		r := rand.New(rand.NewSource(1337))
+		g := &subtractionSearch{Topo: topo, Pile: 7, Scored: true}
+		s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, ScoreBounds: true, NumEpisodes: 100000}
+		if res := s.Search(); res.StopReason != mcts.StopProven {
+			t.Fatalf("TestScoreBoundsTwoPlayer(%d): got StopReason = %v, want %v", topo, res.StopReason, mcts.StopProven)
+		}
+		e := s.RootEntry.BoundedChild()
+		if e == nil {
+			t.Fatalf("TestScoreBoundsTwoPlayer(%d): got BoundedChild = nil", topo)
+		}
+		if got := int(e.Action.(banditAction)); got != 1 {
+			t.Errorf("TestScoreBoundsTwoPlayer(%d): got best action = %d, want 1", topo, got)
+		}
+		// The fastest win against best defense takes 5 plies.
+		if e.Pessimistic > 95 || e.Optimistic < 95 {
+			t.Errorf("TestScoreBoundsTwoPlayer(%d): got bounds [%f, %f], want bounds containing 95", topo, e.Pessimistic, e.Optimistic)
+		}
+	}
+}
+
+func TestScoreBoundsPrune(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	c := &countdownSearch{N: 6}
+	s := mcts.Search[float64]{SearchInterface: c.Interface(), Rand: r, ScoreBounds: true, NumEpisodes: 100000}
+	s.Search()
+
+	// Every child bounded below a sibling has the min priority.
+	var pruned int
+	for _, e := range *s.RootEntry {
+		for _, o := range *s.RootEntry {
+			if e.Optimistic < o.Pessimistic {
+				if e.Priority < o.Priority {
+					t.Errorf("TestScoreBoundsPrune(): got pruned child %v ordered before %v", e.Action, o.Action)
+				}
+				pruned++
+				break
+			}
+		}
+	}
+	if pruned == 0 {
+		t.Errorf("TestScoreBoundsPrune(): got no pruned children")
+	}
+}
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"math/rand"
+
+	"github.com/wenooij/mcts"
+)
+
+// isChance returns true if es are the outcomes of a chance node.
+func isChance[T mcts.Counter](es []*mcts.Edge[T]) bool {
+	return len(es) > 0 && es[0].Probability > 0
+}
+
+// expandChance adds one child to n for each outcome and normalizes their probabilities.
+func (g *graphInterface[T]) expandChance(n *mcts.EdgeList[T], outcomes []mcts.Outcome) {
+	var total float64
+	for _, o := range outcomes {
+		if o.Probability <= 0 {
+			panic("expand: Outcome probability <= 0 for step: " + o.Action.String())
+		}
+		total += o.Probability
+	}
+	g.arena.grow(n, len(outcomes))
+	edges := g.arena.newEdges(len(outcomes))
+	for i, o := range outcomes {
+		p := o.Probability / total
+		node := makeNode[T](mcts.FrontierAction{Action: o.Action, Weight: p})
+		node.PriorWeight = p
+		node.Probability = p
+		edges[i] = mcts.Edge[T]{Src: n, Node: node}
+		*n = append(*n, &edges[i])
+	}
+	g.NumNodes += len(outcomes)
+}
+
+// sampleOutcome returns the index of an outcome sampled by probability.
+func sampleOutcome[T mcts.Counter](es []*mcts.Edge[T], r *rand.Rand) int {
+	x := r.Float64()
+	for i, e := range es {
+		if x -= e.Probability; x < 0 {
+			return i
+		}
+	}
+	return len(es) - 1
+}
+
+// sampleChance returns an outcome sampled by probability for use in rollouts.
+func sampleChance(outcomes []mcts.Outcome, r *rand.Rand) mcts.Action {
+	var total float64
+	for _, o := range outcomes {
+		total += o.Probability
+	}
+	x := r.Float64() * total
+	for _, o := range outcomes {
+		if x -= o.Probability; x < 0 {
+			return o.Action
+		}
+	}
+	return outcomes[len(outcomes)-1].Action
+}
+
+// expectedScore returns the Probability-weighted mean score of the outcomes in es under
+// the given objective, scaled by numRollouts.
+//
+// Only visited outcomes are counted and their probabilities are renormalized.
+// expectedScore returns false if no outcome has been visited.
+func expectedScore[T mcts.Counter](es []*mcts.Edge[T], objective func(T) float64, numRollouts float64) (float64, bool) {
+	var mean, p float64
+	for _, e := range es {
+		if e.NumRollouts == 0 {
+			continue
+		}
+		mean += e.Probability * objective(e.Score.Counter) / e.NumRollouts
+		p += e.Probability
+	}
+	if p == 0 {
+		return 0, false
+	}
+	return numRollouts * mean / p, true
+}
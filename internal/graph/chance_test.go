@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+// gambleSearch is a single decision between a safe payout and a gamble.
+//
+// Action 0 pays Safe. Action 1 leads to a chance node whose outcomes pay
+// 1, 0.5 and 0 with unnormalized weights 5, 3 and 2 for an expected payout of 0.65.
+type gambleSearch struct {
+	Safe    float64
+	gamble  bool
+	outcome int
+	step    int
+}
+
+var gambleOutcomes = []mcts.Outcome{
+	{Action: banditAction(0), Probability: 5},
+	{Action: banditAction(1), Probability: 3},
+	{Action: banditAction(2), Probability: 2},
+}
+
+var gamblePayouts = []float64{1, 0.5, 0}
+
+func (s *gambleSearch) Root() { s.gamble, s.outcome, s.step = false, -1, 0 }
+func (s *gambleSearch) Select(a mcts.Action) bool {
+	switch s.step {
+	case 0:
+		s.gamble = a.(banditAction) == 1
+	case 1:
+		s.outcome = int(a.(banditAction))
+	}
+	s.step++
+	return true
+}
+func (s *gambleSearch) Expand(int) []mcts.FrontierAction {
+	if s.step > 0 {
+		return nil
+	}
+	return []mcts.FrontierAction{{Action: banditAction(0)}, {Action: banditAction(1)}}
+}
+func (s *gambleSearch) Chance() []mcts.Outcome {
+	if s.step == 1 && s.gamble {
+		return gambleOutcomes
+	}
+	return nil
+}
+func (s *gambleSearch) Score() mcts.Score[float64] {
+	var x float64
+	switch {
+	case s.outcome >= 0:
+		x = gamblePayouts[s.outcome]
+	case s.step > 0:
+		x = s.Safe
+	}
+	return mcts.Score[float64]{Counter: x, Objective: func(x float64) float64 { return x }}
+}
+func (s *gambleSearch) Interface() mcts.SearchInterface[float64] {
+	return SearchInterface(mcts.SearchInterface[float64]{
+		Root:   s.Root,
+		Select: s.Select,
+		Expand: s.Expand,
+		Score:  s.Score,
+		Chance: s.Chance,
+		Clone:  func() mcts.SearchInterface[float64] { return (&gambleSearch{Safe: s.Safe}).Interface() },
+	})
+}
+
+func TestChance(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		safe       float64
+		wantAction banditAction
+	}{{
+		name:       "gamble",
+		safe:       0.6,
+		wantAction: 1,
+	}, {
+		name:       "safe",
+		safe:       0.7,
+		wantAction: 0,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1337))
+			g := &gambleSearch{Safe: tc.safe}
+			s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, NumEpisodes: 2000}
+			s.Search()
+
+			best := (*s.RootEntry)[0]
+			for _, e := range *s.RootEntry {
+				if e.NumRollouts > best.NumRollouts {
+					best = e
+				}
+			}
+			if got := best.Action.(banditAction); got != tc.wantAction {
+				t.Errorf("TestChance(%q): got best action %v, want %v", tc.name, got, tc.wantAction)
+			}
+			var gamble *mcts.Edge[float64]
+			for _, e := range *s.RootEntry {
+				if e.Action.(banditAction) == 1 {
+					gamble = e
+				}
+			}
+			if n := len(*gamble.Dst); n != len(gambleOutcomes) {
+				t.Fatalf("TestChance(%q): got %d outcomes, want %d", tc.name, n, len(gambleOutcomes))
+			}
+			var total float64
+			for _, e := range *gamble.Dst {
+				total += e.Probability
+				if want := gambleOutcomes[e.Action.(banditAction)].Probability / 10; math.Abs(e.Probability-want) > 1e-12 {
+					t.Errorf("TestChance(%q): got Probability = %f for outcome %v, want %f", tc.name, e.Probability, e.Action, want)
+				}
+			}
+			if math.Abs(total-1) > 1e-9 {
+				t.Errorf("TestChance(%q): got total Probability = %f, want 1", tc.name, total)
+			}
+			// The expectimax value does not depend on how often each outcome was sampled.
+			score, ok := expectedScore(*gamble.Dst, gamble.Score.Objective, 1)
+			if !ok || math.Abs(score-0.65) > 0.01 {
+				t.Errorf("TestChance(%q): got expected score = %f, want 0.65", tc.name, score)
+			}
+		})
+	}
+}
+
+func TestChanceParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &gambleSearch{Safe: 0.6}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, NumEpisodes: 2000, NumWorkers: 4}
+	s.Search()
+
+	var numRollouts float64
+	for _, e := range *s.RootEntry {
+		numRollouts += e.NumRollouts
+	}
+	if numRollouts != 2000 {
+		t.Errorf("TestChanceParallel(): got %f root rollouts, want 2000", numRollouts)
+	}
+	checkHeap(t, *s.RootEntry)
+}
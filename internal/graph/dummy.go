@@ -14,12 +14,14 @@ type dummyAction int
 func (s dummyAction) String() string { return strconv.FormatInt(int64(s), 10) }
 
 type dummySearch struct {
+	Topo            mcts.Topo
 	BranchFactor    int
+	RootDepth       int
 	depth, MaxDepth int
 	Rand            *rand.Rand
 }
 
-func (s dummySearch) Expand(n int) []mcts.FrontierAction {
+func (s *dummySearch) Expand(n int) []mcts.FrontierAction {
 	if s.MaxDepth > 0 && s.MaxDepth <= s.depth {
 		return nil
 	}
@@ -30,21 +32,33 @@ func (s dummySearch) Expand(n int) []mcts.FrontierAction {
 	return b
 }
 
-func (s *dummySearch) Root()                   { s.depth = 0 }
+func (s *dummySearch) Root()                   { s.depth = s.RootDepth }
 func (s *dummySearch) Select(mcts.Action) bool { s.depth++; return true }
 func (s *dummySearch) Hash() uint64            { return s.Rand.Uint64() }
-func (s dummySearch) Score() mcts.Score[float64] {
+func (s *dummySearch) Score() mcts.Score[float64] {
 	return mcts.Score[float64]{
 		Counter:   s.Rand.NormFloat64(),
 		Objective: func(x float64) float64 { return x },
 	}
 }
 func (s *dummySearch) Interface() mcts.SearchInterface[float64] {
-	return mcts.SearchInterface[float64]{
+	return SearchInterface(mcts.SearchInterface[float64]{
 		Root:   s.Root,
 		Select: s.Select,
 		Expand: s.Expand,
 		Score:  s.Score,
 		Hash:   s.Hash,
+		Clone:  s.Clone,
+		Topo:   s.Topo,
+	})
+}
+func (s *dummySearch) Clone() mcts.SearchInterface[float64] {
+	c := &dummySearch{
+		Topo:         s.Topo,
+		BranchFactor: s.BranchFactor,
+		RootDepth:    s.RootDepth,
+		MaxDepth:     s.MaxDepth,
+		Rand:         rand.New(rand.NewSource(s.Rand.Int63())),
 	}
+	return c.Interface()
 }
@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+// walkSearch is a walk on the integers which ends after MaxDepth steps.
+//
+// Action 0 steps up and action 1 steps down. The score is the final position over MaxDepth.
+// Evaluate returns the current position over MaxDepth.
+// walkState is the position and depth of a walkSearch from Snapshot.
+type walkState struct{ x, depth int }
+
+type walkSearch struct {
+	MaxDepth int
+	x, depth int
+
+	numEvaluate     int
+	numRolloutSteps int
+	terminalEval    bool
+}
+
+func (s *walkSearch) Root() { s.x, s.depth = 0, 0 }
+func (s *walkSearch) Select(a mcts.Action) bool {
+	if a.(banditAction) == 0 {
+		s.x++
+	} else {
+		s.x--
+	}
+	s.depth++
+	return true
+}
+func (s *walkSearch) Expand(n int) []mcts.FrontierAction {
+	if s.depth >= s.MaxDepth {
+		return nil
+	}
+	if n == 1 {
+		s.numRolloutSteps++
+	}
+	return []mcts.FrontierAction{{Action: banditAction(0)}, {Action: banditAction(1)}}
+}
+func (s *walkSearch) Score() mcts.Score[float64] {
+	return mcts.Score[float64]{Counter: float64(s.x) / float64(s.MaxDepth), Objective: func(x float64) float64 { return x }}
+}
+func (s *walkSearch) Evaluate() (float64, float64) {
+	s.numEvaluate++
+	if s.depth >= s.MaxDepth {
+		s.terminalEval = true
+	}
+	return float64(s.x) / float64(s.MaxDepth), 1
+}
+func (s *walkSearch) Snapshot() any { return walkState{s.x, s.depth} }
+func (s *walkSearch) Interface() mcts.SearchInterface[float64] {
+	return SearchInterface(mcts.SearchInterface[float64]{
+		Root:             s.Root,
+		Select:           s.Select,
+		Expand:           s.Expand,
+		Score:            s.Score,
+		Snapshot:         s.Snapshot,
+		Clone:            func() mcts.SearchInterface[float64] { return (&walkSearch{MaxDepth: s.MaxDepth}).Interface() },
+		RolloutInterface: mcts.RolloutInterface[float64]{Evaluate: s.Evaluate},
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		maxDepth     int
+		rolloutDepth int
+	}{{
+		name:     "leaf",
+		maxDepth: 1000,
+	}, {
+		name:         "cutoff",
+		maxDepth:     1000,
+		rolloutDepth: 5,
+	}, {
+		name:         "terminal",
+		maxDepth:     3,
+		rolloutDepth: 2,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1337))
+			g := &walkSearch{MaxDepth: tc.maxDepth}
+			s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, RolloutDepth: tc.rolloutDepth, NumEpisodes: 500}
+			s.Search()
+
+			if got := mostVisited(*s.RootEntry).Action.(banditAction); got != 0 {
+				t.Errorf("TestEvaluate(%q): got best action %v, want 0", tc.name, got)
+			}
+			if g.terminalEval {
+				t.Errorf("TestEvaluate(%q): got Evaluate call on a terminal state", tc.name)
+			}
+			if tc.rolloutDepth == 0 && g.numRolloutSteps != 0 {
+				t.Errorf("TestEvaluate(%q): got %d rollout steps, want 0", tc.name, g.numRolloutSteps)
+			}
+			// Expand(1) is called once more to find whether the cutoff position is terminal.
+			if maxSteps := 500 * (tc.rolloutDepth + 1); g.numRolloutSteps > maxSteps {
+				t.Errorf("TestEvaluate(%q): got %d rollout steps, want at most %d", tc.name, g.numRolloutSteps, maxSteps)
+			}
+			// Terminal states are never reached in the long walks.
+			if tc.maxDepth == 1000 && g.numEvaluate != 500 {
+				t.Errorf("TestEvaluate(%q): got %d calls to Evaluate, want 500", tc.name, g.numEvaluate)
+			}
+		})
+	}
+}
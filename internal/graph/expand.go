@@ -2,41 +2,89 @@ package graph
 
 import (
 	"math/rand"
-	"slices"
 
 	"github.com/wenooij/mcts"
+	"github.com/wenooij/mcts/internal/heap"
 )
 
 // expand calls SearchInterface.Expand to add more Action edges to the given Node.
+//
+// A terminal node is marked for the rollout when Expand returns no actions.
 func (g *graphInterface[T]) expand(s mcts.SearchInterface[T], r *rand.Rand) (hasChild bool) {
-	actions := s.Expand(0)
+	parent := g.ForwardPath[len(g.ForwardPath)-1]
+	if s.Chance != nil {
+		if outcomes := s.Chance(); len(outcomes) > 0 {
+			// Add one child per outcome of the chance node.
+			g.expandChance(g.node(), outcomes)
+			parent.Expanded = true
+			return g.selectExpanded(s, r)
+		}
+	}
+	if s.Simultaneous != nil {
+		if choices := s.Simultaneous(); len(choices) > 0 {
+			// Children are added as each JointAction is chosen.
+			g.expandJoint(g.node(), choices, r)
+			parent.Expanded = true
+			return g.selectExpanded(s, r)
+		}
+	}
+	limit := g.childLimit(parent.NumRollouts)
+	var actions []mcts.FrontierAction
+	if g.bestReply && s.Player() != g.rootPlayer {
+		// Merge the turns of the opponents.
+		// All replies are added at once.
+		actions, limit = g.bestReplies(s), 0
+	} else {
+		actions = expandLimit(s, limit)
+	}
 	if len(actions) == 0 {
+		g.terminal = true
+		if g.solver && parent.Proof == mcts.Unproven {
+			// Mark the terminal state as proven.
+			setProof(parent, s.Score().Counter, 0)
+			v := parent.Score.Objective(parent.ProofCounter)
+			parent.Pessimistic, parent.Optimistic = v, v
+		}
 		return false
 	}
+	parent.Expanded = limit <= 0 || len(actions) < limit
 	// Avoid bias from generation order.
 	r.Shuffle(len(actions), func(i, j int) { actions[i], actions[j] = actions[j], actions[i] })
 
-	n := g.ForwardPath[len(g.ForwardPath)-1].Src
-	*n = slices.Grow(*n, len(actions))
+	n := g.node()
+	applyPriors(s, actions)
+	g.addChildren(n, actions)
+	g.expanded = true
+	if g.fpu != nil {
+		// Order the new children by first-play urgency.
+		g.updatePriorities(*n, parent.NumRollouts, g.exploreFactor)
+		heap.Init(*n)
+	}
+	return g.selectExpanded(s, r)
+}
 
-	var totalWeight float64
-	for _, a := range actions {
-		// Dst will be filled in on the next Select.
-		// We call Hash after the next Select.
-		edge := &mcts.Edge[T]{Src: n, Dst: nil, Node: makeNode[T](a)}
-		*n = append(*n, edge)
-		// Sum predictor weights to later normalize.
-		totalWeight += edge.PriorWeight
-	}
-	// Normalize predictor weight.
-	if totalWeight == 0 {
-		panic("expand: got totalWeight = 0")
-	}
-	// Normalize the weights.
-	for i := range *n {
-		(*n)[i].PriorWeight /= totalWeight
+// selectExpanded selects a child of the newly expanded node for the rollout.
+//
+// With Evaluate, no child is selected so that the expanded node is evaluated.
+func (g *graphInterface[T]) selectExpanded(s mcts.SearchInterface[T], r *rand.Rand) (hasChild bool) {
+	if g.evaluate {
+		return false
 	}
 	// Select a child element to expand.
-	hasChild, _ = g.selectChild(s)
+	hasChild, _ = g.selectChild(s, r)
 	return hasChild
 }
+
+// applyPriors replaces the Weight of each of actions using Priors when it is set.
+func applyPriors[T mcts.Counter](s mcts.SearchInterface[T], actions []mcts.FrontierAction) {
+	if s.Priors == nil || len(actions) == 0 {
+		return
+	}
+	priors := s.Priors(actions)
+	if len(priors) != len(actions) {
+		panic("expand: Priors returned a different number of weights than Actions")
+	}
+	for i, w := range priors {
+		actions[i].Weight = w
+	}
+}
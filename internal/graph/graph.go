@@ -1,4 +1,8 @@
-// Package graph provides an internal interface for the graph model topology.
+// Package graph provides an internal interface for the builtin tree and graph model topologies.
+//
+// The tree topology is a special case of the graph topology in which every selected edge
+// creates a new EdgeList and Hash is never used. The open-loop topology is a tree topology
+// in which the children of each node are filtered by the Actions available in each episode.
 package graph
 
 import (
@@ -8,43 +12,167 @@ import (
 	"github.com/wenooij/mcts"
 )
 
-type graphInterface[T mcts.Counter] struct {
-	RootEntry *mcts.EdgeList[T]
+// graphState is the search structure shared by all workers.
+type graphState[T mcts.Counter] struct {
+	// Topo is the topology of the search structure.
+	Topo mcts.Topo
+
+	// RootEdge is a sentinel Edge with the root EdgeList as Dst.
+	//
+	// RootEdge is always the first element of ForwardPath.
+	// It keeps the total score and number of rollouts at the root.
+	RootEdge *mcts.Edge[T]
 
 	// Table is the collection of Hashed Nodes and children.
+	//
+	// Table is not used in the tree topology.
 	Table map[uint64]*mcts.EdgeList[T]
 
-	ForwardPath []*mcts.Edge[T]
-
 	// InverseTable is only used for the default Hash implementation.
 	//
 	// NOTE: Key of *EdgeList prevents EdgeLists from changing freely.
 	InverseTable map[*mcts.EdgeList[T]]uint64
-	m            maphash.Hash
+	seed         maphash.Seed
+
+	// NumNodes is the number of Edges in the search structure.
+	NumNodes int
+
+	// arena allocates Edges and EdgeLists and is reused after Reset.
+	arena arena[T]
+
+	// joint holds the PlayerStats of simultaneous move nodes.
+	//
+	// NOTE: Key of *EdgeList prevents EdgeLists from changing freely.
+	joint map[*mcts.EdgeList[T]][]mcts.PlayerStats[T]
+
+	// exploreFactor, policy, temperature, and virtualLoss are set from the Search on Init.
+	//
+	// sampler is set when policy is a SamplingPolicy.
+	// amaf is set when policy is an AMAFPolicy.
+	// solver and bounds are set from Search.Solver and Search.ScoreBounds.
+	// fpu is set from Search.FirstPlayUrgency.
+	// widenFactor and widenExponent are set from Search.WideningFactor and Search.WideningExponent.
+	// filterAvailable is set with SearchInterface.Determinize or TopoOpenLoop when the
+	// available Actions are found again in every episode.
+	// jointPolicy is set from Search.JointPolicy.
+	// playerObjectives is the objective of the Edges of each player under Search.Reduction or nil.
+	// rootPlayer is the root player when the playerObjectives are valued against the root player
+	// or -1. bestReply is set when the turns of the opponents are merged for Best-Reply Search.
+	// evaluate is set when RolloutInterface.Evaluate or Search.BatchEvaluator is set
+	// and rolloutDepth is set from Search.RolloutDepth.
+	// virtualLoss is 0 unless Search.NumWorkers > 1 or Search.BatchSize > 1.
+	exploreFactor    float64
+	policy           mcts.SelectionPolicy
+	sampler          mcts.SamplingPolicy
+	amaf             bool
+	solver           bool
+	bounds           bool
+	fpu              mcts.FirstPlayUrgency
+	widenFactor      float64
+	widenExponent    float64
+	temperature      float64
+	virtualLoss      float64
+	filterAvailable  bool
+	jointPolicy      mcts.JointPolicy
+	playerObjectives []func(T) float64
+	rootPlayer       int
+	bestReply        bool
+	evaluate         bool
+	rolloutDepth     int
+}
+
+type graphInterface[T mcts.Counter] struct {
+	*graphState[T]
+
+	ForwardPath []*mcts.Edge[T]
+
+	// weights is scratch space for sampling children by temperature
+	// and for the probabilities of a JointPolicy.
+	weights []float64
+
+	// jointSteps and jointChoices record the choices made at simultaneous move nodes
+	// on the ForwardPath for backprop.
+	jointSteps   []jointStep
+	jointChoices []jointChoice
+
+	// terminal is set when the current node of the episode was found to be terminal.
+	terminal bool
+
+	// expanded is set when the current node of the episode was expanded.
+	// leafEdges are the children of the last pending leaf passed to a BatchEvaluator.
+	expanded  bool
+	leafEdges []*mcts.Edge[T]
+
+	// trace records the actions of the last default rollout when amaf is set.
+	// players and tracePlayers record the player choosing each Action of the ForwardPath
+	// and of the trace when amaf is set, or -1 at chance and simultaneous move nodes.
+	// ply is the number of decisions in the episode so far.
+	// played is scratch space for AMAF updates.
+	trace        []mcts.Action
+	players      []int
+	tracePlayers []int
+	ply          int
+	played       map[amafKey]struct{}
+
+	// available, candidates and candidateIndex are scratch space for selecting
+	// among the available children in an episode.
+	available      map[string]struct{}
+	candidates     mcts.EdgeList[T]
+	candidateIndex []int
+
+	m maphash.Hash
 }
 
-// SearchInterface wraps the search interface using the internal graph topoology.
+// SearchInterface wraps the search interface using the internal topology selected by s.Topo.
 func SearchInterface[T mcts.Counter](s mcts.SearchInterface[T]) mcts.SearchInterface[T] {
-	g := &graphInterface[T]{}
-	_ = g
+	g := &graphInterface[T]{graphState: &graphState[T]{Topo: s.Topo}}
 	s.InternalInterface = g.InternalInterface()
 	return s
 }
 
 func (g *graphInterface[T]) InternalInterface() mcts.InternalInterface[T] {
 	return mcts.InternalInterface[T]{
-		Init:        g.init,
-		Reset:       g.reset,
-		Root:        g.Root,
-		Backprop:    g.backprop,
-		Rollout:     rollout[T],
-		Expand:      g.expand,
-		SelectChild: g.selectChild,
-		MakeNode:    makeNode[T],
+		Init:         g.init,
+		Reset:        g.reset,
+		Root:         g.Root,
+		Backprop:     g.backprop,
+		Rollout:      g.rollout,
+		Expand:       g.expand,
+		SelectChild:  g.selectChild,
+		MakeNode:     makeNode[T],
+		Fork:         g.fork,
+		NumNodes:     g.numNodes,
+		AdvanceRoot:  g.advanceRoot,
+		Solved:       g.rootSolved,
+		RootComplete: g.rootComplete,
+		Prune:        g.pruneNodes,
+		JointStats:   g.jointStats,
+		Leaf:         g.leaf,
+		SetPriors:    g.setPriors,
+	}
+}
+
+// fork returns an InternalInterface sharing the search structure with g.
+func (g *graphInterface[T]) fork(s *mcts.SearchInterface[T]) mcts.InternalInterface[T] {
+	w := &graphInterface[T]{graphState: g.graphState}
+	if w.InverseTable != nil {
+		// The default Hash depends on the ForwardPath of the worker.
+		s.Hash = w.defaultHash()
 	}
+	return w.InternalInterface()
 }
 
+func (g *graphInterface[T]) numNodes() int { return g.NumNodes }
+
 func (g *graphInterface[T]) reset(s *mcts.Search[T]) {
+	g.RootEdge = nil
+	g.NumNodes = 0
+	g.joint = nil
+	g.arena.reset()
+	g.ForwardPath = g.ForwardPath[:0]
+	if g.tree() {
+		return
+	}
 	g.Table = make(map[uint64]*mcts.EdgeList[T], 64)
 	if g.InverseTable != nil {
 		g.InverseTable = nil
@@ -53,42 +181,120 @@ func (g *graphInterface[T]) reset(s *mcts.Search[T]) {
 }
 
 func (g *graphInterface[T]) init(s *mcts.Search[T]) {
+	if g.Topo != s.Topo {
+		// The topology was changed after the SearchInterface was created.
+		if g.RootEdge != nil {
+			g.reset(s)
+		}
+		g.Topo = s.Topo
+	}
+	g.exploreFactor = s.ExploreFactor
+	g.policy = s.SelectionPolicy
+	g.sampler, _ = s.SelectionPolicy.(mcts.SamplingPolicy)
+	_, g.amaf = s.SelectionPolicy.(mcts.AMAFPolicy)
+	g.solver = s.Solver
+	g.bounds = s.ScoreBounds
+	g.fpu = s.FirstPlayUrgency
+	g.widenFactor = s.WideningFactor
+	g.widenExponent = s.WideningExponent
+	g.filterAvailable = s.Determinize != nil || g.Topo == mcts.TopoOpenLoop
+	if g.filterAvailable {
+		// Every available Action is added as it is found.
+		// Bounds of children which may be unavailable do not bound their parent.
+		g.widenFactor = 0
+		g.bounds = false
+	}
+	g.temperature = s.SelectTemperature
+	g.jointPolicy = s.JointPolicy
+	g.evaluate = s.RolloutInterface.Evaluate != nil || s.BatchEvaluator != nil
+	g.rolloutDepth = s.RolloutDepth
+	g.playerObjectives, g.rootPlayer = nil, -1
+	g.bestReply = s.Reduction == mcts.BestReply
+	if s.Reduction != mcts.ReductionNone {
+		// Find the root player.
+		s.Root()
+		p := s.Player()
+		g.playerObjectives = reduceObjectives(s.Reduction, s.PlayerObjectives, p)
+		if s.Reduction != mcts.MaxN {
+			g.rootPlayer = p
+		}
+	}
+	// Pruning can only free memory when Edges do not share slabs.
+	g.arena.bounded = s.NodeBudget > 0 || s.ByteBudget > 0
+	g.virtualLoss = 0
+	if s.NumWorkers > 1 || s.BatchEvaluator != nil && s.BatchSize > 1 {
+		g.virtualLoss = s.VirtualLoss
+	}
+	if g.tree() {
+		if g.RootEdge == nil {
+			s.Root()
+			g.RootEdge = newRootEdge(g.arena.newEdgeList())
+			initializeScore(s.SearchInterface, g.RootEdge)
+		}
+		s.RootEntry = g.RootEdge.Dst
+		return
+	}
 	if g.Table == nil {
 		g.Table = make(map[uint64]*mcts.EdgeList[T], 64)
 	}
 	if s.Hash == nil {
 		g.InverseTable = make(map[*mcts.EdgeList[T]]uint64, 64)
-		g.m.SetSeed(maphash.MakeSeed())
-		var b [8]byte
-		// Provide a default hash implementation which hashes the last state and the next move.
-		s.Hash = func() uint64 {
-			g.m.Reset()
-			if len(g.ForwardPath) == 0 {
-				return g.m.Sum64()
-			}
-			binary.BigEndian.PutUint64(b[:], g.InverseTable[g.ForwardPath[len(g.ForwardPath)-1].Src])
-			g.m.Write(b[:])
-			g.m.WriteString(g.ForwardPath[len(g.ForwardPath)-1].Node.Action.String())
-			return g.m.Sum64()
-		}
+		g.seed = maphash.MakeSeed()
+		s.Hash = g.defaultHash()
 	}
 	// Find the root hash node.
-	if g.RootEntry == nil {
+	if g.RootEdge == nil {
 		s.Root()
 		h := s.Hash()
 		e, ok := g.Table[h]
 		if !ok {
 			// Initialize root.
-			e = &mcts.EdgeList[T]{}
+			e = g.arena.newEdgeList()
 			g.Table[h] = e
 			if g.InverseTable != nil {
 				g.InverseTable[e] = h
 			}
 		}
-		g.RootEntry = e
+		g.RootEdge = newRootEdge(e)
+		initializeScore(s.SearchInterface, g.RootEdge)
 	}
+	s.RootEntry = g.RootEdge.Dst
 }
 
 func (g *graphInterface[T]) Root() {
-	g.ForwardPath = g.ForwardPath[:0]
+	g.ForwardPath = append(g.ForwardPath[:0], g.RootEdge)
+	g.jointSteps, g.jointChoices = g.jointSteps[:0], g.jointChoices[:0]
+	g.terminal, g.expanded = false, false
+	g.players, g.ply = g.players[:0], 0
+}
+
+// tree returns true if every selected edge creates a new EdgeList and Hash is never used.
+func (g *graphState[T]) tree() bool { return g.Topo != mcts.TopoGraph }
+
+// node returns the EdgeList at the end of the ForwardPath.
+func (g *graphInterface[T]) node() *mcts.EdgeList[T] {
+	return g.ForwardPath[len(g.ForwardPath)-1].Dst
+}
+
+// defaultHash provides a default hash implementation which hashes the last state and the next move.
+func (g *graphInterface[T]) defaultHash() func() uint64 {
+	g.m.SetSeed(g.seed)
+	return func() uint64 {
+		if len(g.ForwardPath) <= 1 {
+			g.m.Reset()
+			return g.m.Sum64()
+		}
+		e := g.ForwardPath[len(g.ForwardPath)-1]
+		return g.hashChild(g.InverseTable[e.Src], e.Action)
+	}
+}
+
+// hashChild computes the default hash of the child reached by applying a to the EdgeList with hash h.
+func (g *graphInterface[T]) hashChild(h uint64, a mcts.Action) uint64 {
+	var b [8]byte
+	g.m.Reset()
+	binary.BigEndian.PutUint64(b[:], h)
+	g.m.Write(b[:])
+	g.m.WriteString(a.String())
+	return g.m.Sum64()
 }
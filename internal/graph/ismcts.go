@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"math/rand"
+
+	"github.com/wenooij/mcts"
+)
+
+// chooseAvailable returns the index in n of the child to select among the children
+// whose Actions are available in the current episode.
+//
+// Available Actions which are not yet in n are added first and the availability
+// counts of the available children are incremented. chooseAvailable returns false
+// if no Actions are available.
+//
+// precondition: n is the current node and n is not a chance node.
+func (g *graphInterface[T]) chooseAvailable(s mcts.SearchInterface[T], n *mcts.EdgeList[T], r *rand.Rand) (int, bool) {
+	actions := s.Expand(0)
+	if len(actions) == 0 {
+		return 0, false
+	}
+	if g.available == nil {
+		g.available = make(map[string]struct{}, len(actions))
+	}
+	clear(g.available)
+	for _, a := range actions {
+		g.available[a.Action.String()] = struct{}{}
+	}
+	var numKnown int
+	for _, e := range *n {
+		if _, ok := g.available[e.Action.String()]; ok {
+			numKnown++
+		}
+	}
+	if numKnown < len(g.available) {
+		// Add Actions seen for the first time in this episode.
+		// Avoid bias from generation order.
+		r.Shuffle(len(actions), func(i, j int) { actions[i], actions[j] = actions[j], actions[i] })
+		applyPriors(s, actions)
+		g.addChildren(n, actions)
+	}
+	g.candidates, g.candidateIndex = g.candidates[:0], g.candidateIndex[:0]
+	for i, e := range *n {
+		if _, ok := g.available[e.Action.String()]; ok {
+			e.NumAvailable++
+			g.candidates = append(g.candidates, e)
+			g.candidateIndex = append(g.candidateIndex, i)
+		}
+	}
+	// Priorities depend on availability, so they are computed for each selection.
+	// The heap order of n is restored on the next call to backprop.
+	sib := g.siblings(g.candidates)
+	best := 0
+	for j, e := range g.candidates {
+		e.Priority = g.priority(e, e.NumAvailable, g.exploreFactor, sib)
+		if e.Priority < g.candidates[best].Priority {
+			best = j
+		}
+	}
+	// chooseChild expects the min priority first.
+	g.candidates[0], g.candidates[best] = g.candidates[best], g.candidates[0]
+	g.candidateIndex[0], g.candidateIndex[best] = g.candidateIndex[best], g.candidateIndex[0]
+	return g.candidateIndex[g.chooseChild(g.candidates, r)], true
+}
@@ -0,0 +1,136 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+// hiddenCardSearch is a game where the player bets or folds against a hidden card.
+//
+// Folding pays 0.25. After a bet, the opponent plays their card which pays 1 for cards
+// 0, 1 and 2 and -1 for card 3. The actual card is 3 but Determinize samples a card uniformly
+// so that the expected payout of a bet is 0.5.
+type hiddenCardSearch struct {
+	card           int
+	bet            bool
+	step           int
+	numDeterminize int
+}
+
+func (s *hiddenCardSearch) Root()                    { s.card, s.bet, s.step = 3, false, 0 }
+func (s *hiddenCardSearch) Determinize(r *rand.Rand) { s.card = r.Intn(4); s.numDeterminize++ }
+func (s *hiddenCardSearch) Select(a mcts.Action) bool {
+	if s.step == 0 {
+		s.bet = a.(banditAction) == 1
+	}
+	s.step++
+	return true
+}
+func (s *hiddenCardSearch) Expand(int) []mcts.FrontierAction {
+	switch {
+	case s.step == 0:
+		return []mcts.FrontierAction{{Action: banditAction(0)}, {Action: banditAction(1)}}
+	case s.step == 1 && s.bet:
+		// Only the opponent's card is available.
+		return []mcts.FrontierAction{{Action: banditAction(s.card)}}
+	default:
+		return nil
+	}
+}
+func (s *hiddenCardSearch) Score() mcts.Score[float64] {
+	var x float64
+	switch {
+	case s.step == 1 && !s.bet:
+		x = 0.25
+	case s.step == 2 && s.card < 3:
+		x = 1
+	case s.step == 2:
+		x = -1
+	}
+	return mcts.Score[float64]{Counter: x, Objective: func(x float64) float64 { return x }}
+}
+func (s *hiddenCardSearch) Interface(determinize bool) mcts.SearchInterface[float64] {
+	si := mcts.SearchInterface[float64]{
+		Root:   s.Root,
+		Select: s.Select,
+		Expand: s.Expand,
+		Score:  s.Score,
+		Clone:  func() mcts.SearchInterface[float64] { return (&hiddenCardSearch{}).Interface(determinize) },
+	}
+	if determinize {
+		si.Determinize = s.Determinize
+	}
+	return SearchInterface(si)
+}
+
+func mostVisited[T mcts.Counter](es mcts.EdgeList[T]) *mcts.Edge[T] {
+	best := es[0]
+	for _, e := range es {
+		if e.NumRollouts > best.NumRollouts {
+			best = e
+		}
+	}
+	return best
+}
+
+func TestISMCTS(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &hiddenCardSearch{}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(true), Rand: r, NumEpisodes: 1000}
+	s.Search()
+
+	if g.numDeterminize != 1000 {
+		t.Errorf("TestISMCTS(): got %d calls to Determinize, want 1000", g.numDeterminize)
+	}
+	bet := mostVisited(*s.RootEntry)
+	if got := bet.Action.(banditAction); got != 1 {
+		t.Fatalf("TestISMCTS(): got best action %v, want 1", got)
+	}
+	// The opponent node is one information set with a child for each sampled card.
+	if n := len(*bet.Dst); n != 4 {
+		t.Fatalf("TestISMCTS(): got %d opponent children, want 4", n)
+	}
+	for _, e := range *bet.Dst {
+		// The only available child is always selected.
+		if e.NumAvailable != e.NumRollouts {
+			t.Errorf("TestISMCTS(): got NumAvailable = %f for card %v, want NumRollouts = %f", e.NumAvailable, e.Action, e.NumRollouts)
+		}
+	}
+	// Without Determinize the actual card is searched and folding is best.
+	s = mcts.Search[float64]{SearchInterface: (&hiddenCardSearch{}).Interface(false), Rand: r, NumEpisodes: 1000}
+	s.Search()
+	if got := mostVisited(*s.RootEntry).Action.(banditAction); got != 0 {
+		t.Errorf("TestISMCTS(): got best action %v without Determinize, want 0", got)
+	}
+}
+
+func TestISMCTSParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &hiddenCardSearch{}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(true), Rand: r, NumEpisodes: 1000, NumWorkers: 4}
+	s.Search()
+
+	if got := mostVisited(*s.RootEntry).Action.(banditAction); got != 1 {
+		t.Errorf("TestISMCTSParallel(): got best action %v, want 1", got)
+	}
+}
+
+func TestISMCTSSolver(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &hiddenCardSearch{}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(true), Rand: r, Solver: true, NumEpisodes: 2000}
+	res := s.Search()
+
+	// Children seen in some determinizations do not prove their parent.
+	if res.StopReason != mcts.StopEpisodes {
+		t.Errorf("TestISMCTSSolver(): got StopReason = %v after %d episodes, want %v", res.StopReason, res.NumEpisodes, mcts.StopEpisodes)
+	}
+	for _, e := range *s.RootEntry {
+		// The losing card is the only reply in some determinizations.
+		if e.Action.(banditAction) == 1 && e.Proof != mcts.Unproven {
+			t.Errorf("TestISMCTSSolver(): got Proof = %v for the bet, want Unproven", e.Proof)
+		}
+	}
+}
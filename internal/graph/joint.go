@@ -0,0 +1,120 @@
+package graph
+
+import (
+	"math/rand"
+	"slices"
+
+	"github.com/wenooij/mcts"
+)
+
+// jointStep records the choices made at a simultaneous move node during selection.
+type jointStep struct {
+	// depth is the index in ForwardPath of the Edge leading to the simultaneous move node.
+	depth int
+	// start is the index in jointChoices of the choice of the first player.
+	start int
+}
+
+// jointChoice is the index of the Marginal chosen by a player and its probability.
+type jointChoice struct {
+	index int
+	p     float64
+}
+
+// expandJoint initializes the PlayerStats of the simultaneous move node n.
+//
+// Children are added as each JointAction is chosen.
+func (g *graphInterface[T]) expandJoint(n *mcts.EdgeList[T], choices []mcts.PlayerChoice[T], r *rand.Rand) {
+	stats := make([]mcts.PlayerStats[T], len(choices))
+	for p, c := range choices {
+		if len(c.Actions) == 0 {
+			panic("expand: Simultaneous returned no Actions for a player")
+		}
+		ms := make([]mcts.Marginal, len(c.Actions))
+		var totalWeight float64
+		for i, a := range c.Actions {
+			ms[i] = mcts.Marginal{Action: a.Action, PriorWeight: priorWeight(a)}
+			totalWeight += ms[i].PriorWeight
+		}
+		for i := range ms {
+			ms[i].PriorWeight /= totalWeight
+		}
+		// Avoid bias from generation order.
+		r.Shuffle(len(ms), func(i, j int) { ms[i], ms[j] = ms[j], ms[i] })
+		stats[p] = mcts.PlayerStats[T]{Objective: c.Objective, Marginals: ms}
+	}
+	if g.joint == nil {
+		g.joint = make(map[*mcts.EdgeList[T]][]mcts.PlayerStats[T])
+	}
+	g.joint[n] = stats
+}
+
+// chooseJoint chooses an Action for each player at the simultaneous move node n
+// and returns the index of the child for the JointAction.
+//
+// The child is added to n the first time the JointAction is chosen.
+func (g *graphInterface[T]) chooseJoint(n *mcts.EdgeList[T], stats []mcts.PlayerStats[T], r *rand.Rand) int {
+	g.jointSteps = append(g.jointSteps, jointStep{depth: len(g.ForwardPath) - 1, start: len(g.jointChoices)})
+	joint := make(mcts.JointAction, len(stats))
+	for p, ps := range stats {
+		g.weights = slices.Grow(g.weights[:0], len(ps.Marginals))[:len(ps.Marginals)]
+		g.jointPolicy.Probabilities(g.weights, ps.Marginals, g.exploreFactor)
+		i := sampleWeights(g.weights, r)
+		g.jointChoices = append(g.jointChoices, jointChoice{index: i, p: g.weights[i]})
+		joint[p] = ps.Marginals[i].Action
+	}
+	key := joint.String()
+	for i, e := range *n {
+		if e.Action.String() == key {
+			return i
+		}
+	}
+	g.addChildren(n, []mcts.FrontierAction{{Action: joint}})
+	return len(*n) - 1
+}
+
+// dropJointStep discards the choices made by the last call to chooseJoint.
+func (g *graphInterface[T]) dropJointStep() {
+	step := g.jointSteps[len(g.jointSteps)-1]
+	g.jointSteps = g.jointSteps[:len(g.jointSteps)-1]
+	g.jointChoices = g.jointChoices[:step.start]
+}
+
+// updateJoint updates the Marginals chosen at the simultaneous move node n with
+// the rollout counters.
+func (g *graphInterface[T]) updateJoint(n *mcts.EdgeList[T], step jointStep, counters T, numRollouts float64) {
+	for p, ps := range g.joint[n] {
+		c := g.jointChoices[step.start+p]
+		score := ps.Objective(counters)
+		m := &ps.Marginals[c.index]
+		m.Score += score
+		m.NumRollouts += numRollouts
+		g.jointPolicy.Update(ps.Marginals, c.index, c.p, score/numRollouts)
+	}
+}
+
+// jointStats returns the PlayerStats of the simultaneous move node n or nil.
+func (g *graphInterface[T]) jointStats(n *mcts.EdgeList[T]) []mcts.PlayerStats[T] {
+	return g.joint[n]
+}
+
+// sampleWeights samples an index with probability proportional to weights.
+func sampleWeights(weights []float64, r *rand.Rand) int {
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+	x := r.Float64() * sum
+	for i, w := range weights {
+		if x -= w; x < 0 {
+			return i
+		}
+	}
+	// Rounding errors may leave x >= 0.
+	for i := len(weights) - 1; i > 0; i-- {
+		if weights[i] > 0 {
+			return i
+		}
+	}
+	return 0
+}
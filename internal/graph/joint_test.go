@@ -0,0 +1,172 @@
+package graph
+
+import (
+	"math"
+	"math/rand"
+	randv2 "math/rand/v2"
+	"testing"
+
+	"github.com/wenooij/mcts"
+	"github.com/wenooij/mcts/searchops"
+)
+
+// penniesSearch is a biased matching pennies game where both players move at once.
+//
+// Player 1 scores 1 when both play heads, -0.5 when both play tails, and -1 otherwise.
+// Player 2 scores the negation. In the equilibrium both players play heads with probability 1/5.
+type penniesSearch struct {
+	rootJoint mcts.JointAction
+	joint     mcts.JointAction
+}
+
+var penniesPayoffs = [2][2]float64{{1, -1}, {-1, -0.5}}
+
+func (s *penniesSearch) Root() { s.joint = s.rootJoint }
+func (s *penniesSearch) Select(a mcts.Action) bool {
+	s.joint = a.(mcts.JointAction)
+	return true
+}
+func (s *penniesSearch) Expand(int) []mcts.FrontierAction { return nil }
+func (s *penniesSearch) Simultaneous() []mcts.PlayerChoice[float64] {
+	if s.joint != nil {
+		return nil
+	}
+	actions := []mcts.FrontierAction{{Action: banditAction(0)}, {Action: banditAction(1)}}
+	return []mcts.PlayerChoice[float64]{
+		{Actions: actions, Objective: func(x float64) float64 { return x }},
+		{Actions: actions, Objective: func(x float64) float64 { return -x }},
+	}
+}
+func (s *penniesSearch) Score() mcts.Score[float64] {
+	var x float64
+	if s.joint != nil {
+		x = penniesPayoffs[s.joint[0].(banditAction)][s.joint[1].(banditAction)]
+	}
+	return mcts.Score[float64]{Counter: x, Objective: func(x float64) float64 { return x }}
+}
+func (s *penniesSearch) Interface() mcts.SearchInterface[float64] {
+	return SearchInterface(mcts.SearchInterface[float64]{
+		Root:         s.Root,
+		Select:       s.Select,
+		Expand:       s.Expand,
+		Score:        s.Score,
+		Simultaneous: s.Simultaneous,
+		Clone:        func() mcts.SearchInterface[float64] { return (&penniesSearch{}).Interface() },
+	})
+}
+
+// headsProbability returns the probability of heads in the strategy of each player at the root.
+func headsProbability(t *testing.T, s *mcts.Search[float64]) [2]float64 {
+	t.Helper()
+	stats := s.JointStats(s.RootEntry)
+	if len(stats) != 2 {
+		t.Fatalf("JointStats(): got %d players, want 2", len(stats))
+	}
+	var heads [2]float64
+	for p, ps := range stats {
+		var total float64
+		for i, x := range ps.Strategy() {
+			total += x
+			if ps.Marginals[i].Action.(banditAction) == 0 {
+				heads[p] = x
+			}
+		}
+		if math.Abs(total-1) > 1e-9 {
+			t.Errorf("Strategy(): got total probability = %f for player %d, want 1", total, p)
+		}
+	}
+	return heads
+}
+
+func TestJointPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		policy    mcts.JointPolicy
+		tolerance float64
+	}{{
+		// DecoupledUCT is not guaranteed to converge to an equilibrium.
+		name:      "DecoupledUCT",
+		policy:    mcts.DecoupledUCT{},
+		tolerance: 1,
+	}, {
+		name:      "EXP3",
+		policy:    mcts.EXP3{},
+		tolerance: 0.1,
+	}, {
+		name:      "RegretMatching",
+		policy:    mcts.RegretMatching{},
+		tolerance: 0.1,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1337))
+			g := &penniesSearch{}
+			s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, JointPolicy: tc.policy, NumEpisodes: 20000}
+			s.Search()
+
+			if n := len(*s.RootEntry); n != 4 {
+				t.Errorf("TestJointPolicies(%q): got %d joint children, want 4", tc.name, n)
+			}
+			for p, x := range headsProbability(t, &s) {
+				if math.Abs(x-0.2) > tc.tolerance {
+					t.Errorf("TestJointPolicies(%q): got P(heads) = %f for player %d, want %f", tc.name, x, p, 0.2)
+				}
+			}
+		})
+	}
+}
+
+func TestJointParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &penniesSearch{}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, JointPolicy: mcts.RegretMatching{}, NumEpisodes: 5000, NumWorkers: 4}
+	s.Search()
+
+	var numRollouts float64
+	for _, m := range s.JointStats(s.RootEntry)[0].Marginals {
+		numRollouts += m.NumRollouts
+	}
+	if numRollouts != 5000 {
+		t.Errorf("TestJointParallel(): got %f rollouts for player 1, want 5000", numRollouts)
+	}
+}
+
+func TestJointPrincipalVariation(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &penniesSearch{}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, JointPolicy: mcts.RegretMatching{}, NumEpisodes: 1000}
+	s.Search()
+
+	pv := searchops.PrincipalVariation(searchops.NewExplorer(s.RootEntry), randv2.New(randv2.NewPCG(1, 2)), searchops.FirstNode)
+	if len(pv) != 1 {
+		t.Fatalf("TestJointPrincipalVariation(): got PV of length %d, want 1", len(pv))
+	}
+	if _, ok := pv[0].Action.(mcts.JointAction); !ok {
+		t.Errorf("TestJointPrincipalVariation(): got Action %v, want a JointAction", pv[0].Action)
+	}
+	// JointActions are matched by String so an equal JointAction selects the same child.
+	a := append(mcts.JointAction(nil), pv[0].Action.(mcts.JointAction)...)
+	if e := searchops.Child(s.RootEntry, a); e == nil || e.Action.String() != a.String() {
+		t.Errorf("TestJointPrincipalVariation(): Child(%v) did not find the PV child", a)
+	}
+}
+
+func TestJointAdvanceRoot(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &penniesSearch{}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, JointPolicy: mcts.RegretMatching{}, NumEpisodes: 1000}
+	s.Search()
+
+	a := mcts.JointAction{banditAction(0), banditAction(1)}
+	want := searchops.Child(s.RootEntry, a)
+	if want == nil {
+		t.Fatalf("TestJointAdvanceRoot(): Child(%v) = nil, want a child", a)
+	}
+	wantNodes, _ := countNodes(want.Dst)
+	g.rootJoint = a
+	if !s.AdvanceRoot(mcts.JointAction{banditAction(0), banditAction(1)}) {
+		t.Fatalf("TestJointAdvanceRoot(): AdvanceRoot(%v) returned false", a)
+	}
+	if got, _ := countNodes(s.RootEntry); got != wantNodes {
+		t.Errorf("TestJointAdvanceRoot(): got %d Nodes at the root, want the %d Nodes of the existing subtree", got, wantNodes)
+	}
+}
@@ -9,6 +9,20 @@ import (
 
 // makeNode creates a tree node element.
 func makeNode[T mcts.Counter](action mcts.FrontierAction) mcts.Node[T] {
+	return mcts.Node[T]{
+		// Max priority for new nodes.
+		// This will be recomputed after the first attempt.
+		Priority:    math.Inf(-1),
+		PriorWeight: priorWeight(action),
+		Action:      action.Action,
+		Pessimistic: math.Inf(-1),
+		Optimistic:  math.Inf(1),
+	}
+}
+
+// priorWeight returns the unnormalized prior weight of action.
+// Zero weights are treated as 1.
+func priorWeight(action mcts.FrontierAction) float64 {
 	weight := action.Weight
 	if weight < 0 {
 		panic("mcts.Node: Predictor weight < 0 for step: " + action.Action.String())
@@ -16,13 +30,12 @@ func makeNode[T mcts.Counter](action mcts.FrontierAction) mcts.Node[T] {
 	if weight == 0 {
 		weight = 1
 	}
-	return mcts.Node[T]{
-		// Max priority for new nodes.
-		// This will be recomputed after the first attempt.
-		Priority:    math.Inf(-1),
-		PriorWeight: weight,
-		Action:      action.Action,
-	}
+	return weight
+}
+
+// newRootEdge creates a root sentinel Edge for dst.
+func newRootEdge[T mcts.Counter](dst *mcts.EdgeList[T]) *mcts.Edge[T] {
+	return &mcts.Edge[T]{Dst: dst, Node: mcts.Node[T]{Pessimistic: math.Inf(-1), Optimistic: math.Inf(1)}}
 }
 
 type explorer[T mcts.Counter] struct{ root *mcts.EdgeList[T] }
@@ -32,10 +45,8 @@ func newExplorerInterface[T mcts.Counter](root *mcts.EdgeList[T]) *explorer[T] {
 }
 
 func (x *explorer[T]) explore(a mcts.Action) *explorer[T] {
-	for _, e := range *x.root {
-		if e.Action == a {
-			return newExplorerInterface(e.Dst)
-		}
+	if e := searchops.Child(x.root, a); e != nil {
+		return newExplorerInterface(e.Dst)
 	}
 	return nil
 }
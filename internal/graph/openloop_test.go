@@ -0,0 +1,115 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+// noisySearch is a two step game with a stochastic transition hidden inside Select.
+//
+// Action 0 leads to a state with a single Action paying 0.5. Action 1 leads with equal
+// probability to a lucky state with Actions 2 and 3 paying 1 and 0 or to an unlucky state
+// with Actions 4 and 5 paying 0.2 and -1. With best play, Action 1 pays 0.6 on average.
+type noisySearch struct {
+	state   int
+	payout  float64
+	step    int
+	numHash int
+	Rand    *rand.Rand
+}
+
+var noisyPayouts = map[banditAction]float64{2: 1, 3: 0, 4: 0.2, 5: -1, 6: 0.5}
+
+func (s *noisySearch) Root() { s.state, s.payout, s.step = 0, 0, 0 }
+func (s *noisySearch) Select(a mcts.Action) bool {
+	s.step++
+	switch {
+	case s.step == 1 && a.(banditAction) == 0:
+		s.state = 0
+	case s.step == 1:
+		s.state = 1 + s.Rand.Intn(2)
+	default:
+		s.payout = noisyPayouts[a.(banditAction)]
+	}
+	return true
+}
+func (s *noisySearch) Expand(int) []mcts.FrontierAction {
+	if s.step == 0 {
+		return []mcts.FrontierAction{{Action: banditAction(0)}, {Action: banditAction(1)}}
+	}
+	if s.step > 1 {
+		return nil
+	}
+	switch s.state {
+	case 0:
+		return []mcts.FrontierAction{{Action: banditAction(6)}}
+	case 1:
+		return []mcts.FrontierAction{{Action: banditAction(2)}, {Action: banditAction(3)}}
+	default:
+		return []mcts.FrontierAction{{Action: banditAction(4)}, {Action: banditAction(5)}}
+	}
+}
+func (s *noisySearch) Score() mcts.Score[float64] {
+	return mcts.Score[float64]{Counter: s.payout, Objective: func(x float64) float64 { return x }}
+}
+func (s *noisySearch) Hash() uint64 { s.numHash++; return 0 }
+func (s *noisySearch) Interface() mcts.SearchInterface[float64] {
+	return SearchInterface(mcts.SearchInterface[float64]{
+		Root:   s.Root,
+		Select: s.Select,
+		Expand: s.Expand,
+		Score:  s.Score,
+		Hash:   s.Hash,
+		Clone: func() mcts.SearchInterface[float64] {
+			return (&noisySearch{Rand: rand.New(rand.NewSource(s.Rand.Int63()))}).Interface()
+		},
+		Topo: mcts.TopoOpenLoop,
+	})
+}
+
+func TestOpenLoop(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &noisySearch{Rand: rand.New(rand.NewSource(1338))}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, NumEpisodes: 2000}
+	s.Search()
+
+	if g.numHash != 0 {
+		t.Errorf("TestOpenLoop(): got %d calls to Hash, want 0", g.numHash)
+	}
+	risky := mostVisited(*s.RootEntry)
+	if got := risky.Action.(banditAction); got != 1 {
+		t.Fatalf("TestOpenLoop(): got best action %v, want 1", got)
+	}
+	// The children of every realized state are kept in a single node.
+	if n := len(*risky.Dst); n != 4 {
+		t.Fatalf("TestOpenLoop(): got %d children after action 1, want 4", n)
+	}
+	var numAvailable, numSelected float64
+	for _, e := range *risky.Dst {
+		if e.NumRollouts > e.NumAvailable {
+			t.Errorf("TestOpenLoop(): got NumRollouts = %f > NumAvailable = %f for %v", e.NumRollouts, e.NumAvailable, e.Action)
+		}
+		numAvailable += e.NumAvailable
+		numSelected += e.NumRollouts
+	}
+	// Two children are available in every episode through the node.
+	if numAvailable != 2*numSelected {
+		t.Errorf("TestOpenLoop(): got total NumAvailable = %f, want %f", numAvailable, 2*numSelected)
+	}
+	if best := mostVisited(*risky.Dst).Action.(banditAction); best != 2 && best != 4 {
+		t.Errorf("TestOpenLoop(): got best action %v after action 1, want 2 or 4", best)
+	}
+}
+
+func TestOpenLoopParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &noisySearch{Rand: rand.New(rand.NewSource(1338))}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, NumEpisodes: 2000, NumWorkers: 4}
+	s.Search()
+
+	if got := mostVisited(*s.RootEntry).Action.(banditAction); got != 1 {
+		t.Errorf("TestOpenLoopParallel(): got best action %v, want 1", got)
+	}
+}
@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+func TestParallelSearch(t *testing.T) {
+	const numEpisodes = 1000
+
+	for _, topo := range []mcts.Topo{mcts.TopoDefault, mcts.TopoGraph} {
+		r := rand.New(rand.NewSource(1337))
+		s := mcts.Search[float64]{
+			SearchInterface: (&dummySearch{Topo: topo, BranchFactor: 3, MaxDepth: 5, Rand: r}).Interface(),
+			Rand:            r,
+			NumEpisodes:     numEpisodes,
+			NumWorkers:      4,
+		}
+		s.Search()
+
+		var numRollouts float64
+		for _, e := range *s.RootEntry {
+			numRollouts += e.NumRollouts
+		}
+		if numRollouts != numEpisodes {
+			t.Errorf("TestParallelSearch(%d): got root rollouts = %f, want %d", topo, numRollouts, numEpisodes)
+		}
+		// Check that all virtual loss was released.
+		var walk func(n *mcts.EdgeList[float64])
+		walk = func(n *mcts.EdgeList[float64]) {
+			if n == nil {
+				return
+			}
+			for _, e := range *n {
+				if e.NumInflight != 0 {
+					t.Fatalf("TestParallelSearch(%d, %s): got NumInflight = %d, want 0", topo, e.Action, e.NumInflight)
+				}
+				walk(e.Dst)
+			}
+		}
+		walk(s.RootEntry)
+	}
+}
+
+func TestParallelSearchQuality(t *testing.T) {
+	const numEpisodes = 2000
+
+	// share returns the fraction of rollouts spent on the best arm.
+	share := func(numWorkers int) float64 {
+		r := rand.New(rand.NewSource(1337))
+		b := &banditSearch{P: []float64{.2, .5, .8}, Rand: r}
+		s := mcts.Search[float64]{SearchInterface: b.Interface(), Rand: r, NumEpisodes: numEpisodes, NumWorkers: numWorkers}
+		s.Search()
+
+		best := mostVisited(*s.RootEntry)
+		if got := best.Action.(banditAction); got != 2 {
+			t.Errorf("TestParallelSearchQuality(%d): got best action %v, want 2", numWorkers, got)
+		}
+		return best.NumRollouts / numEpisodes
+	}
+	serial, parallel := share(1), share(4)
+	// Virtual loss spreads the workers over more arms but the best arm must still dominate.
+	if parallel < serial-0.05 {
+		t.Errorf("TestParallelSearchQuality(): got %f of rollouts on the best arm with 4 workers, want at least %f as in serial search", parallel, serial-0.05)
+	}
+}
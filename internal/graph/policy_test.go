@@ -0,0 +1,254 @@
+package graph
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+type banditAction int
+
+func (a banditAction) String() string { return strconv.Itoa(int(a)) }
+
+// banditSearch is a single step search over Bernoulli arms.
+// Weights optionally sets the prior weight of each arm.
+type banditSearch struct {
+	P       []float64
+	Weights []float64
+	arm     int
+	Rand    *rand.Rand
+}
+
+func (s *banditSearch) Root() { s.arm = -1 }
+func (s *banditSearch) Select(a mcts.Action) bool {
+	s.arm = int(a.(banditAction))
+	return true
+}
+func (s *banditSearch) Expand(int) []mcts.FrontierAction {
+	if s.arm >= 0 {
+		return nil
+	}
+	actions := make([]mcts.FrontierAction, len(s.P))
+	for i := range actions {
+		actions[i] = mcts.FrontierAction{Action: banditAction(i)}
+		if s.Weights != nil {
+			actions[i].Weight = s.Weights[i]
+		}
+	}
+	return actions
+}
+func (s *banditSearch) Score() mcts.Score[float64] {
+	var x float64
+	if s.arm >= 0 && s.Rand.Float64() < s.P[s.arm] {
+		x = 1
+	}
+	return mcts.Score[float64]{Counter: x, Objective: func(x float64) float64 { return x }}
+}
+func (s *banditSearch) Interface() mcts.SearchInterface[float64] {
+	return SearchInterface(mcts.SearchInterface[float64]{
+		Root:   s.Root,
+		Select: s.Select,
+		Expand: s.Expand,
+		Score:  s.Score,
+		Clone:  s.Clone,
+	})
+}
+func (s *banditSearch) Clone() mcts.SearchInterface[float64] {
+	c := &banditSearch{P: s.P, Weights: s.Weights, Rand: rand.New(rand.NewSource(s.Rand.Int63()))}
+	return c.Interface()
+}
+
+type greedyPolicy struct{ calls *int }
+
+func (p greedyPolicy) Value(s mcts.SelectionStats) float64 {
+	*p.calls++
+	if s.NumRollouts <= 0 {
+		panic("greedyPolicy: unexpected call for unvisited edge")
+	}
+	return s.Mean() + 1/s.NumRollouts
+}
+
+func TestSelectionPolicies(t *testing.T) {
+	var calls int
+	for _, tc := range []struct {
+		name   string
+		policy mcts.SelectionPolicy
+	}{
+		{"default", nil},
+		{"PUCB", mcts.PUCB{}},
+		{"UCB1", mcts.UCB1{}},
+		{"PUCT", mcts.PUCT{}},
+		{"PUCT/AlphaZero", mcts.PUCT{CBase: 19652, CInit: 1.25}},
+		{"UCB1Tuned", mcts.UCB1Tuned{}},
+		{"custom", greedyPolicy{&calls}},
+		{"Thompson/Beta", mcts.Thompson{MaxScore: 1}},
+		{"Thompson/NormalGamma", mcts.Thompson{Posterior: mcts.PosteriorNormalGamma}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1337))
+			b := &banditSearch{P: []float64{.2, .5, .8}, Rand: r}
+			s := mcts.Search[float64]{SearchInterface: b.Interface(), Rand: r, SelectionPolicy: tc.policy, NumEpisodes: 2000}
+			s.Search()
+
+			best := (*s.RootEntry)[0]
+			for _, e := range *s.RootEntry {
+				if e.NumRollouts > best.NumRollouts {
+					best = e
+				}
+			}
+			if got := int(best.Action.(banditAction)); got != 2 {
+				t.Errorf("TestSelectionPolicies(%s): got best arm = %d, want 2", tc.name, got)
+			}
+			checkHeap(t, *s.RootEntry)
+		})
+	}
+	if calls == 0 {
+		t.Errorf("TestSelectionPolicies(): custom policy was never called")
+	}
+}
+
+func checkHeap[T mcts.Counter](t *testing.T, es mcts.EdgeList[T]) {
+	t.Helper()
+	for i := 1; i < len(es); i++ {
+		if parent := (i - 1) / 2; es[i].Priority < es[parent].Priority {
+			t.Fatalf("checkHeap(): heap violated at index %d", i)
+		}
+	}
+}
+
+func TestUCB1Tuned(t *testing.T) {
+	// Half of 10 rollouts scored 1, so the sample variance is 1/4.
+	s := mcts.SelectionStats{Score: 5, SquaredScore: 5, NumRollouts: 10, NumParentRollouts: 100}
+	logN := math.Log(100)
+	v := .25 + math.Sqrt(2*logN/10)
+	want := .5 + math.Sqrt(logN/10*min(.25, v))
+	if got := (mcts.UCB1Tuned{}).Value(s); math.Abs(got-want) > 1e-12 {
+		t.Errorf("TestUCB1Tuned(): got %f, want %f", got, want)
+	}
+}
+
+func TestThompsonBeta(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy mcts.Thompson
+		score  float64
+	}{
+		// 6 wins and 4 losses of 10 rollouts.
+		{"win/loss", mcts.Thompson{}, 6 - 4},
+		{"0/1", mcts.Thompson{MaxScore: 1}, 6},
+	} {
+		s := mcts.SelectionStats{Score: tc.score, NumRollouts: 10}
+		if got, want := tc.policy.Value(s), 7.0/12; math.Abs(got-want) > 1e-12 {
+			t.Errorf("TestThompsonBeta(%s): got posterior mean %f, want %f", tc.name, got, want)
+		}
+	}
+
+	// Bernoulli arms scoring +1 or -1.
+	r := rand.New(rand.NewSource(1337))
+	b := &banditSearch{P: []float64{.2, .5, .8}, Rand: r}
+	si := b.Interface()
+	si.Score = func() mcts.Score[float64] {
+		score := b.Score()
+		score.Counter = 2*score.Counter - 1
+		return score
+	}
+	s := mcts.Search[float64]{SearchInterface: si, Rand: r, SelectionPolicy: mcts.Thompson{}, NumEpisodes: 2000}
+	s.Search()
+	if got := mostVisited(*s.RootEntry).Action.(banditAction); got != 2 {
+		t.Errorf("TestThompsonBeta(win/loss): got best arm = %d, want 2", got)
+	}
+}
+
+func TestThompsonParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	d := &dummySearch{BranchFactor: 4, MaxDepth: 6, Rand: r}
+	s := mcts.Search[float64]{
+		SearchInterface: d.Interface(),
+		Rand:            r,
+		SelectionPolicy: mcts.Thompson{Posterior: mcts.PosteriorNormalGamma},
+		NumEpisodes:     1000,
+		NumWorkers:      4,
+	}
+	s.Search()
+
+	var numRollouts float64
+	for _, e := range *s.RootEntry {
+		numRollouts += e.NumRollouts
+		if e.NumInflight != 0 {
+			t.Errorf("TestThompsonParallel(): got NumInflight = %d, want 0", e.NumInflight)
+		}
+	}
+	if numRollouts != 1000 {
+		t.Errorf("TestThompsonParallel(): got root rollouts = %f, want 1000", numRollouts)
+	}
+	checkHeap(t, *s.RootEntry)
+}
+
+func TestSelectTemperature(t *testing.T) {
+	minRollouts := func(temperature float64) float64 {
+		r := rand.New(rand.NewSource(1337))
+		b := &banditSearch{P: []float64{.2, .5, .8}, Rand: r}
+		s := mcts.Search[float64]{SearchInterface: b.Interface(), Rand: r, SelectTemperature: temperature, NumEpisodes: 3000}
+		s.Search()
+		checkHeap(t, *s.RootEntry)
+		n := math.Inf(1)
+		for _, e := range *s.RootEntry {
+			n = min(n, e.NumRollouts)
+		}
+		return n
+	}
+	cold, hot := minRollouts(0), minRollouts(100)
+	if hot < 800 {
+		t.Errorf("TestSelectTemperature(): got min rollouts = %f at high temperature, want >= 800", hot)
+	}
+	if cold >= hot {
+		t.Errorf("TestSelectTemperature(): got min rollouts = %f at zero temperature, want < %f", cold, hot)
+	}
+}
+
+func TestFirstPlayUrgency(t *testing.T) {
+	// 100 arms where the first arm is best and strongly preferred by the prior.
+	p, weights := make([]float64, 100), make([]float64, 100)
+	for i := range p {
+		p[i], weights[i] = .1, .1
+	}
+	p[0], weights[0] = .9, 100
+	for _, tc := range []struct {
+		name        string
+		fpu         mcts.FirstPlayUrgency
+		wantVisited func(int) bool
+	}{
+		{"default", nil, func(n int) bool { return n == 100 }},
+		{"constant", mcts.FPUConstant(100), func(n int) bool { return n == 100 }},
+		{"reduction", mcts.FPUReduction(.5), func(n int) bool { return n < 50 }},
+		{"zero", mcts.FPUConstant(0), func(n int) bool { return n < 50 }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1337))
+			b := &banditSearch{P: p, Weights: weights, Rand: r}
+			s := mcts.Search[float64]{SearchInterface: b.Interface(), Rand: r, FirstPlayUrgency: tc.fpu, NumEpisodes: 200}
+			s.Search()
+
+			var visited int
+			var best *mcts.Edge[float64]
+			for _, e := range *s.RootEntry {
+				if e.NumRollouts > 0 {
+					visited++
+				}
+				if best == nil || e.NumRollouts > best.NumRollouts {
+					best = e
+				}
+			}
+			if !tc.wantVisited(visited) {
+				t.Errorf("TestFirstPlayUrgency(%s): got %d visited arms", tc.name, visited)
+			}
+			if got := int(best.Action.(banditAction)); got != 0 {
+				t.Errorf("TestFirstPlayUrgency(%s): got best arm = %d, want 0", tc.name, got)
+			}
+			checkHeap(t, *s.RootEntry)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package graph
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/wenooij/mcts"
+)
+
+func TestPonder(t *testing.T) {
+	for _, topo := range []mcts.Topo{mcts.TopoDefault, mcts.TopoGraph} {
+		r := rand.New(rand.NewSource(1337))
+		d := &dummySearch{Topo: topo, BranchFactor: 3, MaxDepth: 8, Rand: r}
+		s := mcts.Search[float64]{SearchInterface: d.Interface(), Rand: r, NumEpisodes: 100}
+		s.Search()
+
+		// Play our move.
+		d.RootDepth = 1
+		s.AdvanceRoot((*s.RootEntry)[0].Action)
+
+		// Ponder while the opponent thinks.
+		p := s.Ponder(context.Background())
+		time.Sleep(10 * time.Millisecond)
+		res := p.Stop()
+		if res.StopReason != mcts.StopCanceled {
+			t.Errorf("TestPonder(%d): got StopReason = %v, want %v", topo, res.StopReason, mcts.StopCanceled)
+		}
+		if res.NumEpisodes == 0 {
+			t.Fatalf("TestPonder(%d): got NumEpisodes = 0, want > 0", topo)
+		}
+		if s.NumEpisodes != 100 {
+			t.Errorf("TestPonder(%d): got NumEpisodes = %d after Stop, want 100", topo, s.NumEpisodes)
+		}
+
+		// Play the opponent move.
+		move := (*s.RootEntry)[0]
+		wantNodes, _ := countNodes(move.Dst)
+		d.RootDepth = 2
+		if !s.AdvanceRoot(move.Action) {
+			t.Fatalf("TestPonder(%d): AdvanceRoot(%s) returned false", topo, move.Action)
+		}
+		if got, _ := countNodes(s.RootEntry); wantNodes == 0 || got != wantNodes {
+			t.Errorf("TestPonder(%d): got %d Nodes at the root, want the %d Nodes of the pondered subtree", topo, got, wantNodes)
+		}
+		if res := s.Search(); res.NumEpisodes != 100 {
+			t.Errorf("TestPonder(%d): got NumEpisodes = %d, want 100", topo, res.NumEpisodes)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+func TestPriors(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &walkSearch{MaxDepth: 20}
+	si := g.Interface()
+	si.RolloutInterface = mcts.RolloutInterface[float64]{}
+	var numPriors int
+	si.Priors = func(actions []mcts.FrontierAction) []float64 {
+		numPriors++
+		priors := make([]float64, len(actions))
+		for i, a := range actions {
+			priors[i] = 1
+			if a.Action.(banditAction) == 0 {
+				priors[i] = 3
+			}
+		}
+		return priors
+	}
+	s := mcts.Search[float64]{SearchInterface: si, Rand: r, NumEpisodes: 200}
+	s.Search()
+
+	if g.numRolloutSteps == 0 {
+		t.Fatalf("TestPriors(): got 0 rollout steps, want > 0")
+	}
+	// Priors is called once for each node expanded in the tree.
+	if want := numExpanded(*s.RootEntry) + 1; numPriors != want {
+		t.Errorf("TestPriors(): got %d calls to Priors, want %d", numPriors, want)
+	}
+	for _, c := range *s.RootEntry {
+		want := 0.25
+		if c.Action.(banditAction) == 0 {
+			want = 0.75
+		}
+		if math.Abs(c.PriorWeight-want) > 1e-9 {
+			t.Errorf("TestPriors(): got PriorWeight = %f for %v, want %f", c.PriorWeight, c.Action, want)
+		}
+	}
+}
+
+// numExpanded returns the number of expanded nodes in the tree below es.
+func numExpanded(es mcts.EdgeList[float64]) int {
+	var n int
+	for _, e := range es {
+		if e.Dst != nil && len(*e.Dst) > 0 {
+			n += 1 + numExpanded(*e.Dst)
+		}
+	}
+	return n
+}
@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/wenooij/mcts"
+)
+
+// pruneNodes collapses the subtrees with the fewest rollouts until at most maxNodes Nodes remain.
+//
+// Collapsed Edges keep their statistics and Dst pointers but their EdgeLists are emptied.
+// They will be expanded again when selected.
+// pruneNodes returns the number of Nodes pruned.
+func (g *graphInterface[T]) pruneNodes(maxNodes int) int {
+	if g.NumNodes <= maxNodes {
+		return 0
+	}
+	// Collect expanded Edges below the root.
+	var (
+		candidates []*mcts.Edge[T]
+		visited    = make(map[*mcts.EdgeList[T]]struct{})
+		stack      = []*mcts.EdgeList[T]{g.RootEdge.Dst}
+	)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, e := range *n {
+			if e.Dst == nil || len(*e.Dst) == 0 {
+				continue
+			}
+			if _, ok := visited[e.Dst]; ok {
+				continue
+			}
+			visited[e.Dst] = struct{}{}
+			candidates = append(candidates, e)
+			stack = append(stack, e.Dst)
+		}
+	}
+	// Descendants have no more rollouts than their ancestors so they tend to be collapsed first.
+	slices.SortStableFunc(candidates, func(a, b *mcts.Edge[T]) int { return cmp.Compare(a.NumRollouts, b.NumRollouts) })
+	numNodes := g.NumNodes
+	for _, e := range candidates {
+		if numNodes <= maxNodes {
+			break
+		}
+		numNodes -= len(*e.Dst)
+		*e.Dst = nil
+		e.Expanded = false
+	}
+	before := g.NumNodes
+	g.dropUnreachable()
+	return before - g.NumNodes
+}
+
+// dropUnreachable drops EdgeLists which are unreachable from the root and recounts NumNodes.
+//
+// The PlayerStats of unreachable simultaneous move nodes are also dropped.
+//
+// The arena no longer retains its slabs so unreachable Edges can be collected.
+// When the arena is not bounded, the reachable EdgeLists are moved to fresh slabs
+// since a surviving Edge would otherwise keep its whole slab alive.
+func (g *graphInterface[T]) dropUnreachable() {
+	g.arena.release()
+	// reachable maps each reachable EdgeList to its new location.
+	reachable := make(map[*mcts.EdgeList[T]]*mcts.EdgeList[T])
+	g.NumNodes = 0
+	stack := []*mcts.EdgeList[T]{g.RootEdge.Dst}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := reachable[n]; ok {
+			continue
+		}
+		reachable[n] = n
+		g.NumNodes += len(*n)
+		for _, e := range *n {
+			if e.Dst != nil {
+				stack = append(stack, e.Dst)
+			}
+		}
+	}
+	if !g.arena.bounded {
+		g.moveReachable(reachable)
+		g.RootEdge.Dst = reachable[g.RootEdge.Dst]
+	}
+	for h, n := range g.Table {
+		if m, ok := reachable[n]; ok {
+			g.Table[h] = m
+		} else {
+			delete(g.Table, h)
+		}
+	}
+	if g.InverseTable != nil {
+		inverse := make(map[*mcts.EdgeList[T]]uint64, len(reachable))
+		for n, h := range g.InverseTable {
+			if m, ok := reachable[n]; ok {
+				inverse[m] = h
+			}
+		}
+		g.InverseTable = inverse
+	}
+	if g.joint != nil {
+		joint := make(map[*mcts.EdgeList[T]][]mcts.PlayerStats[T], len(g.joint))
+		for n, stats := range g.joint {
+			if m, ok := reachable[n]; ok {
+				joint[m] = stats
+			}
+		}
+		g.joint = joint
+	}
+}
+
+// moveReachable copies each reachable EdgeList and its Edges to the arena
+// and replaces the value of each entry of reachable with its copy.
+func (g *graphInterface[T]) moveReachable(reachable map[*mcts.EdgeList[T]]*mcts.EdgeList[T]) {
+	for n := range reachable {
+		reachable[n] = g.arena.newEdgeList()
+	}
+	for n, m := range reachable {
+		g.arena.grow(m, len(*n))
+		edges := g.arena.newEdges(len(*n))
+		for i, e := range *n {
+			edges[i] = *e
+			edges[i].Src = m
+			if e.Dst != nil {
+				edges[i].Dst = reachable[e.Dst]
+			}
+			*m = append(*m, &edges[i])
+		}
+	}
+}
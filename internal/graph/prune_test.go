@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+func TestNodeBudget(t *testing.T) {
+	const (
+		numEpisodes = 2000
+		nodeBudget  = 100
+	)
+
+	for _, numWorkers := range []int{1, 4} {
+		for _, topo := range []mcts.Topo{mcts.TopoDefault, mcts.TopoGraph} {
+			r := rand.New(rand.NewSource(1337))
+			s := mcts.Search[float64]{
+				SearchInterface: (&dummySearch{Topo: topo, BranchFactor: 3, MaxDepth: 10, Rand: r}).Interface(),
+				Rand:            r,
+				NumEpisodes:     numEpisodes,
+				NumWorkers:      numWorkers,
+				NodeBudget:      nodeBudget,
+			}
+			res := s.Search()
+			if res.NumPruned == 0 {
+				t.Errorf("TestNodeBudget(%d, %d): got NumPruned = 0, want > 0", topo, numWorkers)
+			}
+			numNodes, _ := countNodes(s.RootEntry)
+			if got := s.InternalInterface.NumNodes(); got != numNodes || got > nodeBudget {
+				t.Errorf("TestNodeBudget(%d, %d): got NumNodes = %d, want %d reachable and at most %d", topo, numWorkers, got, numNodes, nodeBudget)
+			}
+			// Root statistics are kept.
+			var numRollouts float64
+			for _, e := range *s.RootEntry {
+				numRollouts += e.NumRollouts
+			}
+			if numRollouts != numEpisodes {
+				t.Errorf("TestNodeBudget(%d, %d): got root rollouts = %f, want %d", topo, numWorkers, numRollouts, numEpisodes)
+			}
+		}
+	}
+}
+
+func TestByteBudget(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	s := mcts.Search[float64]{
+		SearchInterface: (&dummySearch{BranchFactor: 3, MaxDepth: 10, Rand: r}).Interface(),
+		Rand:            r,
+		NumEpisodes:     1000,
+		ByteBudget:      1 << 14,
+	}
+	if res := s.Search(); res.NumPruned == 0 {
+		t.Errorf("TestByteBudget(): got NumPruned = 0, want > 0")
+	}
+}
+
+func TestNodeBudgetMemory(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	r := rand.New(rand.NewSource(1337))
+	s := mcts.Search[float64]{
+		SearchInterface: (&dummySearch{BranchFactor: 20, MaxDepth: 30, Rand: r}).Interface(),
+		Rand:            r,
+		NumEpisodes:     5000,
+		NodeBudget:      2000,
+	}
+	s.Search()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(&s)
+
+	// Pruned Edges must be collectable so the heap stays near the size of the budget.
+	// Edges sharing slabs with surviving Edges once held tens of MB here.
+	if got := int64(after.HeapAlloc) - int64(before.HeapAlloc); got > 4<<20 {
+		t.Errorf("TestNodeBudgetMemory(): got heap growth of %d bytes, want at most %d", got, 4<<20)
+	}
+}
@@ -0,0 +1,151 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+// placementSearch picks K distinct items from N.
+// Each item i is worth i/N and the score is a Bernoulli trial of the mean value.
+// Since the order of placements does not matter, AMAF values are unbiased.
+//
+// Players take turns placing items when Players > 0. Zero leaves Player unset.
+type placementSearch struct {
+	N, K    int
+	Players int
+	placed  []int
+	Rand    *rand.Rand
+}
+
+func (s *placementSearch) Root() { s.placed = s.placed[:0] }
+func (s *placementSearch) Select(a mcts.Action) bool {
+	s.placed = append(s.placed, int(a.(banditAction)))
+	return true
+}
+func (s *placementSearch) Expand(int) []mcts.FrontierAction {
+	if len(s.placed) == s.K {
+		return nil
+	}
+	var actions []mcts.FrontierAction
+	for i := range s.N {
+		if !s.isPlaced(i) {
+			actions = append(actions, mcts.FrontierAction{Action: banditAction(i)})
+		}
+	}
+	return actions
+}
+func (s *placementSearch) Player() int { return len(s.placed) % s.Players }
+func (s *placementSearch) isPlaced(i int) bool {
+	for _, j := range s.placed {
+		if i == j {
+			return true
+		}
+	}
+	return false
+}
+func (s *placementSearch) Score() mcts.Score[float64] {
+	var sum float64
+	for _, i := range s.placed {
+		sum += float64(i) / float64(s.N)
+	}
+	var x float64
+	if len(s.placed) > 0 && s.Rand.Float64() < sum/float64(len(s.placed)) {
+		x = 1
+	}
+	return mcts.Score[float64]{Counter: x, Objective: func(x float64) float64 { return x }}
+}
+func (s *placementSearch) Interface() mcts.SearchInterface[float64] {
+	si := mcts.SearchInterface[float64]{
+		Root:   s.Root,
+		Select: s.Select,
+		Expand: s.Expand,
+		Score:  s.Score,
+	}
+	if s.Players > 0 {
+		si.Player = s.Player
+	}
+	return SearchInterface(si)
+}
+
+func TestRAVE(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy mcts.RAVE
+	}{
+		{"default", mcts.RAVE{}},
+		{"equivalence", mcts.RAVE{Beta: mcts.RAVEEquivalence(100)}},
+		{"bias", mcts.RAVE{Policy: mcts.UCB1{}, Beta: mcts.RAVEBias(0.1)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1337))
+			p := &placementSearch{N: 20, K: 4, Players: 1, Rand: r}
+			s := mcts.Search[float64]{SearchInterface: p.Interface(), Rand: r, SelectionPolicy: tc.policy, NumEpisodes: 2000}
+			s.Search()
+
+			var best *mcts.Edge[float64]
+			for _, e := range *s.RootEntry {
+				if e.NumRollouts > 0 && e.NumAMAFRollouts < e.NumRollouts {
+					t.Errorf("TestRAVE(%s): got AMAF rollouts = %f < rollouts = %f for %v", tc.name, e.NumAMAFRollouts, e.NumRollouts, e.Action)
+				}
+				if best == nil || e.NumRollouts > best.NumRollouts {
+					best = e
+				}
+			}
+			if got := int(best.Action.(banditAction)); got < 15 {
+				t.Errorf("TestRAVE(%s): got best item = %d, want >= 15", tc.name, got)
+			}
+		})
+	}
+}
+
+func TestRAVEPlayers(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		players int
+	}{
+		{"alternate", 0},
+		{"Player", 2},
+	} {
+		r := rand.New(rand.NewSource(1337))
+		p := &placementSearch{N: 10, K: 2, Players: tc.players, Rand: r}
+		s := mcts.Search[float64]{SearchInterface: p.Interface(), Rand: r, SelectionPolicy: mcts.RAVE{}, NumEpisodes: 500}
+		s.Search()
+
+		// The root player places a single item so the placements of the opponent are not credited.
+		for _, e := range *s.RootEntry {
+			if e.NumAMAFRollouts != e.NumRollouts {
+				t.Errorf("TestRAVEPlayers(%s): got AMAF rollouts = %f, want rollouts = %f for %v", tc.name, e.NumAMAFRollouts, e.NumRollouts, e.Action)
+			}
+		}
+	}
+}
+
+func TestRAVEDisabled(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	p := &placementSearch{N: 10, K: 3, Rand: r}
+	s := mcts.Search[float64]{SearchInterface: p.Interface(), Rand: r, NumEpisodes: 200}
+	s.Search()
+
+	for _, e := range *s.RootEntry {
+		if e.NumAMAFRollouts != 0 {
+			t.Fatalf("TestRAVEDisabled(): got AMAF rollouts = %f, want 0", e.NumAMAFRollouts)
+		}
+	}
+}
+
+func TestRAVEParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	d := &dummySearch{BranchFactor: 4, MaxDepth: 8, Rand: r}
+	s := mcts.Search[float64]{SearchInterface: d.Interface(), Rand: r, SelectionPolicy: mcts.RAVE{}, NumEpisodes: 500, NumWorkers: 4}
+	s.Search()
+
+	var numAMAFRollouts float64
+	for _, e := range *s.RootEntry {
+		numAMAFRollouts += e.NumAMAFRollouts
+	}
+	if numAMAFRollouts < 500 {
+		t.Errorf("TestRAVEParallel(): got root AMAF rollouts = %f, want >= 500", numAMAFRollouts)
+	}
+}
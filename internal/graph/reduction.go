@@ -0,0 +1,67 @@
+package graph
+
+import "github.com/wenooij/mcts"
+
+// reduceObjectives returns the objective of the Edges of each player under the Reduction r.
+func reduceObjectives[T mcts.Counter](r mcts.Reduction, objectives []func(T) float64, rootPlayer int) []func(T) float64 {
+	reduced := make([]func(T) float64, len(objectives))
+	switch r {
+	case mcts.MaxN:
+		copy(reduced, objectives)
+	case mcts.Paranoid, mcts.BestReply:
+		root := objectives[rootPlayer]
+		opponent := func(x T) float64 { return -root(x) }
+		for p := range reduced {
+			reduced[p] = opponent
+		}
+		reduced[rootPlayer] = root
+	default:
+		return nil
+	}
+	return reduced
+}
+
+// playerObjective returns the objective for the next Edge selected from the current state
+// or nil when the Objective from Score is used.
+//
+// precondition: the current state is a decision node.
+func (g *graphInterface[T]) playerObjective(s mcts.SearchInterface[T]) func(T) float64 {
+	if g.playerObjectives == nil {
+		return nil
+	}
+	return g.playerObjectives[s.Player()]
+}
+
+// bestReplies returns the Actions of every opponent of the root player from the current
+// state as PlayerActions for a merged turn of the opponents.
+func (g *graphInterface[T]) bestReplies(s mcts.SearchInterface[T]) []mcts.FrontierAction {
+	current := s.Player()
+	var actions []mcts.FrontierAction
+	for p := range g.playerObjectives {
+		if p == g.rootPlayer {
+			continue
+		}
+		s.SetPlayer(p)
+		for _, a := range s.Expand(0) {
+			actions = append(actions, mcts.FrontierAction{Action: mcts.PlayerAction{Player: p, Action: a.Action}, Weight: a.Weight})
+		}
+	}
+	s.SetPlayer(current)
+	return actions
+}
+
+// selectAction applies the Action of a selected child with Select.
+//
+// Under BestReply, a PlayerAction is applied by its player and the root player moves next.
+func (g *graphInterface[T]) selectAction(s mcts.SearchInterface[T], a mcts.Action) bool {
+	pa, ok := a.(mcts.PlayerAction)
+	if !g.bestReply || !ok {
+		return s.Select(a)
+	}
+	s.SetPlayer(pa.Player)
+	if !s.Select(pa.Action) {
+		return false
+	}
+	s.SetPlayer(g.rootPlayer)
+	return true
+}
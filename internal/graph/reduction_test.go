@@ -0,0 +1,284 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/wenooij/mcts"
+	"github.com/wenooij/mcts/searchops"
+)
+
+// coalitionSearch is a three player game where player 0 moves and then player 1 replies.
+//
+// After action 0, player 1 chooses between scores (0.6, 0.7, 0) and (0, 0.3, 0).
+// After action 1, player 1 has a single reply scoring (0.4, 0.2, 0.2).
+// Under MaxN player 1 prefers the first reply so action 0 is best for player 0.
+// Under Paranoid player 1 minimizes the score of player 0 so action 1 is best.
+type coalitionSearch struct {
+	rootPath []banditAction
+	path     []banditAction
+}
+
+var coalitionPayouts = map[[2]banditAction][]float64{
+	{0, 0}: {0.6, 0.7, 0},
+	{0, 1}: {0, 0.3, 0},
+	{1, 0}: {0.4, 0.2, 0.2},
+}
+
+func (s *coalitionSearch) Root() { s.path = append(s.path[:0], s.rootPath...) }
+func (s *coalitionSearch) Select(a mcts.Action) bool {
+	s.path = append(s.path, a.(banditAction))
+	return true
+}
+func (s *coalitionSearch) Expand(int) []mcts.FrontierAction {
+	switch {
+	case len(s.path) == 0:
+		return []mcts.FrontierAction{{Action: banditAction(0)}, {Action: banditAction(1)}}
+	case len(s.path) == 1 && s.path[0] == 0:
+		return []mcts.FrontierAction{{Action: banditAction(0)}, {Action: banditAction(1)}}
+	case len(s.path) == 1:
+		return []mcts.FrontierAction{{Action: banditAction(0)}}
+	default:
+		return nil
+	}
+}
+func (s *coalitionSearch) Player() int { return len(s.path) % 3 }
+func (s *coalitionSearch) Score() mcts.Score[[]float64] {
+	var x []float64
+	if len(s.path) == 2 {
+		x = append(x, coalitionPayouts[[2]banditAction{s.path[0], s.path[1]}]...)
+	}
+	// The Objective is replaced by the Reduction.
+	return mcts.Score[[]float64]{Counter: x, Objective: func([]float64) float64 { return 0 }}
+}
+func (s *coalitionSearch) Interface() mcts.SearchInterface[[]float64] {
+	return SearchInterface(mcts.SearchInterface[[]float64]{
+		Root:   s.Root,
+		Select: s.Select,
+		Expand: s.Expand,
+		Score:  s.Score,
+		Player: s.Player,
+		Clone:  func() mcts.SearchInterface[[]float64] { return (&coalitionSearch{}).Interface() },
+	})
+}
+
+// coalitionObjectives maximizes the score component of each player.
+func coalitionObjectives() []func([]float64) float64 {
+	objectives := make([]func([]float64) float64, 3)
+	for p := range objectives {
+		objectives[p] = func(x []float64) float64 {
+			if p >= len(x) {
+				return 0
+			}
+			return x[p]
+		}
+	}
+	return objectives
+}
+
+func TestReduction(t *testing.T) {
+	for _, tc := range []struct {
+		reduction  mcts.Reduction
+		numWorkers int
+		wantAction banditAction
+	}{
+		{reduction: mcts.MaxN, wantAction: 0},
+		{reduction: mcts.Paranoid, wantAction: 1},
+		{reduction: mcts.MaxN, numWorkers: 4, wantAction: 0},
+		{reduction: mcts.Paranoid, numWorkers: 4, wantAction: 1},
+	} {
+		r := rand.New(rand.NewSource(1337))
+		g := &coalitionSearch{}
+		s := mcts.Search[[]float64]{
+			SearchInterface:  g.Interface(),
+			Rand:             r,
+			Reduction:        tc.reduction,
+			PlayerObjectives: coalitionObjectives(),
+			NumEpisodes:      2000,
+			NumWorkers:       tc.numWorkers,
+		}
+		s.Search()
+
+		if got := mostVisited(*s.RootEntry).Action.(banditAction); got != tc.wantAction {
+			t.Errorf("TestReduction(%v, %d): got best action %v, want %v", tc.reduction, tc.numWorkers, got, tc.wantAction)
+		}
+		for _, e := range *s.RootEntry {
+			if e.Dst == nil {
+				continue
+			}
+			// Player 1 is valued by their own component under MaxN and against player 0 otherwise.
+			for _, c := range *e.Dst {
+				want := -c.Score.Counter[0]
+				if tc.reduction == mcts.MaxN {
+					want = c.Score.Counter[1]
+				}
+				if got := c.Score.Apply(); got != want {
+					t.Errorf("TestReduction(%v, %d): got objective %f for reply %v, want %f", tc.reduction, tc.numWorkers, got, c.Action, want)
+				}
+			}
+		}
+	}
+}
+
+// sabotageSearch is a three player game where player 0 chooses a plan and each opponent
+// may then reduce the score of player 0 with a hit. The game ends when player 0 would move again.
+//
+// After action 0, both opponents may hit for 0.5. After action 1, only player 1 may hit for 0.8.
+// Under Paranoid both opponents hit so action 1 is best for player 0.
+// Under BestReply only the opponent with the best reply hits so action 0 is best.
+type sabotageSearch struct {
+	plan   int
+	hits   float64
+	toMove int
+	played bool
+}
+
+var sabotageHits = [2][3][]float64{
+	{nil, {0, 0.5}, {0, 0.5}},
+	{nil, {0, 0.8}, {0}},
+}
+
+func (s *sabotageSearch) Root() { *s = sabotageSearch{} }
+func (s *sabotageSearch) Select(a mcts.Action) bool {
+	i := int(a.(banditAction))
+	if s.toMove == 0 {
+		s.plan, s.played = i, true
+	} else {
+		s.hits += sabotageHits[s.plan][s.toMove][i]
+	}
+	s.toMove = (s.toMove + 1) % 3
+	return true
+}
+func (s *sabotageSearch) Expand(int) []mcts.FrontierAction {
+	switch {
+	case s.toMove == 0 && s.played:
+		return nil
+	case s.toMove == 0:
+		return []mcts.FrontierAction{{Action: banditAction(0)}, {Action: banditAction(1)}}
+	}
+	actions := make([]mcts.FrontierAction, len(sabotageHits[s.plan][s.toMove]))
+	for i := range actions {
+		actions[i] = mcts.FrontierAction{Action: banditAction(i)}
+	}
+	return actions
+}
+func (s *sabotageSearch) Player() int     { return s.toMove }
+func (s *sabotageSearch) SetPlayer(p int) { s.toMove = p }
+func (s *sabotageSearch) Score() mcts.Score[[]float64] {
+	return mcts.Score[[]float64]{Counter: []float64{1 - s.hits, s.hits, s.hits}, Objective: func([]float64) float64 { return 0 }}
+}
+func (s *sabotageSearch) Interface() mcts.SearchInterface[[]float64] {
+	return SearchInterface(mcts.SearchInterface[[]float64]{
+		Root:      s.Root,
+		Select:    s.Select,
+		Expand:    s.Expand,
+		Score:     s.Score,
+		Player:    s.Player,
+		SetPlayer: s.SetPlayer,
+	})
+}
+
+func TestBestReply(t *testing.T) {
+	for _, tc := range []struct {
+		reduction  mcts.Reduction
+		wantAction banditAction
+	}{
+		{reduction: mcts.Paranoid, wantAction: 1},
+		{reduction: mcts.BestReply, wantAction: 0},
+	} {
+		r := rand.New(rand.NewSource(1337))
+		s := mcts.Search[[]float64]{
+			SearchInterface:  (&sabotageSearch{}).Interface(),
+			Rand:             r,
+			Reduction:        tc.reduction,
+			PlayerObjectives: coalitionObjectives(),
+			NumEpisodes:      2000,
+		}
+		s.Search()
+
+		if got := mostVisited(*s.RootEntry).Action.(banditAction); got != tc.wantAction {
+			t.Errorf("TestBestReply(%v): got best action %v, want %v", tc.reduction, got, tc.wantAction)
+		}
+		if tc.reduction != mcts.BestReply {
+			continue
+		}
+		// The replies of both opponents are merged into a single node after action 0.
+		plan := searchops.Child(s.RootEntry, banditAction(0))
+		if plan == nil || plan.Dst == nil {
+			t.Fatalf("TestBestReply(%v): got no node after action 0", tc.reduction)
+		}
+		players := map[int]int{}
+		for _, e := range *plan.Dst {
+			a, ok := e.Action.(mcts.PlayerAction)
+			if !ok {
+				t.Fatalf("TestBestReply(%v): got reply %v, want a PlayerAction", tc.reduction, e.Action)
+			}
+			players[a.Player]++
+			if e.Dst != nil && len(*e.Dst) != 0 {
+				t.Errorf("TestBestReply(%v): got %d children after reply %v, want 0 since player 0 moves again", tc.reduction, len(*e.Dst), a)
+			}
+		}
+		if players[1] != 2 || players[2] != 2 {
+			t.Errorf("TestBestReply(%v): got replies per player %v, want 2 for players 1 and 2", tc.reduction, players)
+		}
+	}
+}
+
+func TestBestReplyRequiresSetPlayer(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("TestBestReplyRequiresSetPlayer(): want panic")
+		}
+	}()
+	si := (&sabotageSearch{}).Interface()
+	si.SetPlayer = nil
+	s := mcts.Search[[]float64]{SearchInterface: si, Reduction: mcts.BestReply, PlayerObjectives: coalitionObjectives()}
+	s.Init()
+}
+
+func TestReductionAdvanceRoot(t *testing.T) {
+	for _, tc := range []struct {
+		reduction mcts.Reduction
+		wantKept  bool
+	}{
+		{reduction: mcts.MaxN, wantKept: true},
+		{reduction: mcts.Paranoid, wantKept: false},
+	} {
+		r := rand.New(rand.NewSource(1337))
+		g := &coalitionSearch{}
+		s := mcts.Search[[]float64]{
+			SearchInterface:  g.Interface(),
+			Rand:             r,
+			Reduction:        tc.reduction,
+			PlayerObjectives: coalitionObjectives(),
+			NumEpisodes:      500,
+		}
+		s.Search()
+
+		// Player 1 becomes the root player.
+		g.rootPath = []banditAction{0}
+		if got := s.AdvanceRoot(banditAction(0)); got != tc.wantKept {
+			t.Errorf("TestReductionAdvanceRoot(%v): got AdvanceRoot() = %v, want %v", tc.reduction, got, tc.wantKept)
+		}
+		s.Search()
+
+		// Every reply is valued by the objective of player 1 as the root player.
+		for _, c := range *s.RootEntry {
+			if got, want := c.Score.Apply(), c.Score.Counter[1]; got != want {
+				t.Errorf("TestReductionAdvanceRoot(%v): got objective %f for reply %v, want %f", tc.reduction, got, c.Action, want)
+			}
+		}
+	}
+}
+
+func TestReductionRequiresPlayer(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("TestReductionRequiresPlayer(): want panic")
+		}
+	}()
+	si := (&coalitionSearch{}).Interface()
+	si.Player = nil
+	s := mcts.Search[[]float64]{SearchInterface: si, Reduction: mcts.MaxN, PlayerObjectives: coalitionObjectives()}
+	s.Init()
+}
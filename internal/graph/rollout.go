@@ -7,26 +7,86 @@ import (
 )
 
 // rollout runs simulated rollouts from the given node and returns the results.
-func rollout[T mcts.Counter](s mcts.SearchInterface[T], ri mcts.RolloutInterface[T], r *rand.Rand) (counters T, numRollouts float64) {
+//
+// Actions selected by the default rollout are recorded in the trace for AMAF updates.
+// With Evaluate, the default rollout is cut off after rolloutDepth Actions.
+func (g *graphInterface[T]) rollout(s mcts.SearchInterface[T], ri mcts.RolloutInterface[T], r *rand.Rand) (counters T, numRollouts float64) {
+	g.trace, g.tracePlayers = g.trace[:0], g.tracePlayers[:0]
+	if e := g.ForwardPath[len(g.ForwardPath)-1]; g.solved(e) {
+		// Return the proven score.
+		return e.ProofCounter, 1
+	}
+	if ri.Evaluate != nil {
+		// Evaluate the leaf after at most rolloutDepth Actions of the default policy.
+		if g.terminal || g.rolloutDepth > 0 && g.playout(s, r, g.rolloutDepth) {
+			return s.Score().Counter, 1
+		}
+		return ri.Evaluate()
+	}
 	if ri.Rollout != nil {
 		// Call the custom Rollout implementation if available.
 		return ri.Rollout()
 	}
 	// Rollout using the default policy (using Expand).
-	for {
-		var ok bool
-		switch actions := s.Expand(1); len(actions) {
-		case 0:
-			// Return the score for the terminal position.
-			return s.Score().Counter, 1
-		case 1:
-			ok = s.Select(actions[0].Action)
-		default:
-			ok = s.Select(actions[r.Intn(len(actions))].Action)
-		}
+	g.playout(s, r, -1)
+	// Return the score for the terminal position.
+	return s.Score().Counter, 1
+}
+
+// playout plays Actions from the default rollout policy until a terminal position is reached
+// or maxDepth Actions have been played. maxDepth < 0 places no limit on the number of Actions.
+// playout returns true if a terminal position was reached.
+func (g *graphInterface[T]) playout(s mcts.SearchInterface[T], r *rand.Rand, maxDepth int) bool {
+	for depth := 0; ; depth++ {
+		a, decision, ok := rolloutAction(s, r)
 		if !ok {
-			// Return the score for the terminal position.
-			return s.Score().Counter, 1
+			return true
+		}
+		if depth == maxDepth {
+			// The position after maxDepth Actions is not terminal.
+			return false
 		}
+		player := -1
+		if g.amaf {
+			player = g.nextPlayer(s, decision)
+		}
+		if !s.Select(a) {
+			return true
+		}
+		if g.amaf {
+			g.trace = append(g.trace, a)
+			g.tracePlayers = g.recordPlayer(g.tracePlayers, player)
+		}
+	}
+}
+
+// rolloutAction returns the next Action for the default rollout policy.
+//
+// Outcomes of chance nodes are sampled by Probability. At simultaneous move nodes
+// each player chooses a uniform random Action. Otherwise a uniform random Action
+// is chosen using Expand. decision is false at chance and simultaneous move nodes.
+// rolloutAction returns ok = false at terminal positions.
+func rolloutAction[T mcts.Counter](s mcts.SearchInterface[T], r *rand.Rand) (a mcts.Action, decision, ok bool) {
+	if s.Chance != nil {
+		if outcomes := s.Chance(); len(outcomes) > 0 {
+			return sampleChance(outcomes, r), false, true
+		}
+	}
+	if s.Simultaneous != nil {
+		if choices := s.Simultaneous(); len(choices) > 0 {
+			joint := make(mcts.JointAction, len(choices))
+			for p, c := range choices {
+				joint[p] = c.Actions[r.Intn(len(c.Actions))].Action
+			}
+			return joint, false, true
+		}
+	}
+	switch actions := s.Expand(1); len(actions) {
+	case 0:
+		return nil, true, false
+	case 1:
+		return actions[0].Action, true, true
+	default:
+		return actions[r.Intn(len(actions))].Action, true, true
 	}
 }
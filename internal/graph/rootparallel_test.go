@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"math/rand"
+	randv2 "math/rand/v2"
+	"testing"
+
+	"github.com/wenooij/mcts"
+	"github.com/wenooij/mcts/searchops"
+)
+
+func TestRootParallel(t *testing.T) {
+	const (
+		numSearches    = 4
+		numEpisodes    = 100
+		numRootActions = 3
+	)
+
+	searches := make([]*mcts.Search[float64], numSearches)
+	for i := range searches {
+		r := rand.New(rand.NewSource(int64(i)))
+		searches[i] = &mcts.Search[float64]{
+			SearchInterface: (&dummySearch{BranchFactor: numRootActions, MaxDepth: 3, Rand: r}).Interface(),
+			Rand:            r,
+			NumEpisodes:     numEpisodes,
+		}
+	}
+	root := mcts.RootParallel(searches...)
+
+	if got := len(*root); got != numRootActions {
+		t.Fatalf("TestRootParallel(): got root actions = %d, want %d", got, numRootActions)
+	}
+	var numRollouts float64
+	for _, e := range *root {
+		numRollouts += e.NumRollouts
+	}
+	if want := float64(numSearches * numEpisodes); numRollouts != want {
+		t.Errorf("TestRootParallel(): got root rollouts = %f, want %f", numRollouts, want)
+	}
+	pv := searchops.PrincipalVariation(searchops.NewExplorer(root), randv2.New(randv2.NewPCG(1, 2)), searchops.FirstNode)
+	if len(pv) != 1 {
+		t.Fatalf("TestRootParallel(): got |PV| = %d, want 1", len(pv))
+	}
+	for _, e := range *root {
+		if e.NumRollouts > pv[0].NumRollouts {
+			t.Errorf("TestRootParallel(): got PV with %f rollouts, but %s has %f rollouts", pv[0].NumRollouts, e.Action, e.NumRollouts)
+		}
+	}
+}
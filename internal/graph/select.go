@@ -1,21 +1,55 @@
 package graph
 
-import "github.com/wenooij/mcts"
+import (
+	"math"
+	"math/rand"
+
+	"github.com/wenooij/mcts"
+	"github.com/wenooij/mcts/internal/heap"
+)
 
 // selectChild selects the highest priority child from the min heap.
-func (g *graphInterface[T]) selectChild(s mcts.SearchInterface[T]) (hasChild, expand bool) {
-	var n *mcts.EdgeList[T]
-	switch len(g.ForwardPath) {
-	case 0:
-		n = g.RootEntry
+//
+// With a SamplingPolicy, the child with the highest sample is selected instead.
+// Outcomes of chance nodes are sampled by Probability.
+// At simultaneous move nodes, each player chooses an Action using the JointPolicy.
+// With Determinize or TopoOpenLoop, only children available in the current episode are selected.
+func (g *graphInterface[T]) selectChild(s mcts.SearchInterface[T], r *rand.Rand) (hasChild, expand bool) {
+	n := g.node()
+	stats, joint := g.joint[n]
+	if len(*n) == 0 && !joint {
+		return false, true
+	}
+	var i int
+	switch {
+	case joint:
+		i = g.chooseJoint(n, stats, r)
+	case isChance(*n):
+		i = sampleOutcome(*n, r)
+	case g.filterAvailable:
+		var ok bool
+		if i, ok = g.chooseAvailable(s, n, r); !ok {
+			// No Actions are available in this episode.
+			g.terminal = true
+			return false, false
+		}
 	default:
-		n = g.ForwardPath[len(g.ForwardPath)-1].Src
+		if g.widenFactor > 0 {
+			g.widen(s)
+		}
+		i = g.chooseChild(*n, r)
 	}
-	if len(*n) == 0 {
-		return false, true
+	child := (*n)[i]
+	var objective func(T) float64
+	if child.Score.Objective == nil && !joint && !isChance(*n) {
+		// The acting player chooses the objective of a new Edge under a Reduction.
+		objective = g.playerObjective(s)
+	}
+	player := -1
+	if g.amaf {
+		player = g.nextPlayer(s, !joint && !isChance(*n))
 	}
-	child := (*n)[0]
-	if !s.Select(child.Action) {
+	if !g.selectAction(s, child.Action) {
 		// Select may return false if this node is no longer legal
 		// Possibly due to the outcome of chance node higher up the tree.
 		// In SearchHash, Select may return false after a cycle is detected
@@ -23,29 +57,94 @@ func (g *graphInterface[T]) selectChild(s mcts.SearchInterface[T]) (hasChild, ex
 		//
 		// In either case return child = nil, expand = false, then
 		// backprop the score from n.
+		if joint {
+			g.dropJointStep()
+		}
 		return false, false
 	}
 	g.ForwardPath = append(g.ForwardPath, child)
+	if g.amaf {
+		g.players = g.recordPlayer(g.players, player)
+	}
 	if child.Dst == nil {
 		// Insert initial node.
 		// We couldn't do this in Expand because Hash
 		// expects to be called only after Select.
-		h := s.Hash()
-		// Dst will already be in Table if dst is a transposition.
-		dst, ok := g.Table[h]
-		if !ok {
-			dst = &mcts.EdgeList[T]{}
-			g.Table[h] = dst
-			if g.InverseTable != nil {
-				g.InverseTable[dst] = h
-			}
-		}
-		child.Dst = dst
+		child.Dst = g.makeDst(s)
 	}
 	initializeScore(s, child)
+	if objective != nil {
+		child.Score.Objective = objective
+	}
+	if g.virtualLoss != 0 {
+		// Apply virtual loss to discourage other workers from selecting child.
+		child.NumInflight++
+		if !joint && !isChance(*n) && !g.filterAvailable {
+			parent := g.ForwardPath[len(g.ForwardPath)-2]
+			child.Priority = g.priority(child, parent.NumRollouts, g.exploreFactor, g.siblings(*n))
+			heap.Fix(*n, i)
+		}
+	}
+	if g.solved(child) {
+		// The outcome below child is already known.
+		// Rollout will return the proven score.
+		return false, false
+	}
 	return true, false
 }
 
+// chooseChild returns the index of the child to select from the heap es.
+//
+// The top of the heap is chosen unless the SelectionPolicy is a SamplingPolicy
+// or the temperature is set. Unvisited children are always chosen first
+// unless FirstPlayUrgency is set.
+func (g *graphInterface[T]) chooseChild(es mcts.EdgeList[T], r *rand.Rand) int {
+	if math.IsInf(es[0].Priority, -1) {
+		return 0
+	}
+	if g.sampler != nil {
+		parent := g.ForwardPath[len(g.ForwardPath)-1]
+		sib := g.siblings(es)
+		best, bestSample := 0, math.Inf(-1)
+		for i, e := range es {
+			if math.IsInf(e.Priority, 1) {
+				// Skip proven losses and pruned children.
+				continue
+			}
+			if sample := g.sampler.Sample(r, g.stats(e, parent.NumRollouts, g.exploreFactor, sib)); sample > bestSample {
+				best, bestSample = i, sample
+			}
+		}
+		return best
+	}
+	if g.temperature > 0 {
+		return g.sampleChild(es, r)
+	}
+	return 0
+}
+
+// sampleChild samples the index of a child from a softmax over the priorities of es.
+//
+// precondition: es[0] has the min priority.
+func (g *graphInterface[T]) sampleChild(es mcts.EdgeList[T], r *rand.Rand) int {
+	// Subtract the max value to keep the weights in range.
+	minPriority := es[0].Priority
+	g.weights = g.weights[:0]
+	var sum float64
+	for _, e := range es {
+		w := math.Exp((minPriority - e.Priority) / g.temperature)
+		g.weights = append(g.weights, w)
+		sum += w
+	}
+	x := r.Float64() * sum
+	for i, w := range g.weights {
+		if x -= w; x < 0 {
+			return i
+		}
+	}
+	return 0
+}
+
 // initializeScore is called when selecting a node for the first time.
 //
 // precondition: n must be the current node (s.Select(n.Action) has been called, or we are at the root).
@@ -55,3 +154,26 @@ func initializeScore[T mcts.Counter](s mcts.SearchInterface[T], e *mcts.Edge[T])
 		e.Score = s.Score()
 	}
 }
+
+// makeDst returns the EdgeList for the current state.
+//
+// In the tree and open-loop topologies, a new EdgeList is always returned.
+// Otherwise, the EdgeList is looked up in the Table by Hash.
+//
+// precondition: s.Select has been called on the selected edge.
+func (g *graphInterface[T]) makeDst(s mcts.SearchInterface[T]) *mcts.EdgeList[T] {
+	if g.tree() {
+		return g.arena.newEdgeList()
+	}
+	h := s.Hash()
+	// Dst will already be in Table if dst is a transposition.
+	dst, ok := g.Table[h]
+	if !ok {
+		dst = g.arena.newEdgeList()
+		g.Table[h] = dst
+		if g.InverseTable != nil {
+			g.InverseTable[dst] = h
+		}
+	}
+	return dst
+}
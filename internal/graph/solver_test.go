@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+// subtractionSearch is the subtraction game where players take 1 or 2 from a pile.
+// The player who takes the last item wins. Piles which are multiples of 3 are lost
+// for the player to move.
+//
+// When Scored is set, the winner scores 100 minus the depth so faster wins are better.
+type subtractionSearch struct {
+	Topo   mcts.Topo
+	Pile   int
+	Scored bool
+	pile   int
+	depth  int
+}
+
+func (s *subtractionSearch) Root() { s.pile, s.depth = s.Pile, 0 }
+func (s *subtractionSearch) Select(a mcts.Action) bool {
+	s.pile -= int(a.(banditAction))
+	s.depth++
+	return true
+}
+func (s *subtractionSearch) Expand(int) []mcts.FrontierAction {
+	var actions []mcts.FrontierAction
+	for n := 1; n <= min(2, s.pile); n++ {
+		actions = append(actions, mcts.FrontierAction{Action: banditAction(n)})
+	}
+	return actions
+}
+func (s *subtractionSearch) Hash() uint64 { return uint64(2*s.pile + s.depth&1) }
+
+// Score returns a positive score if the first player won and negative if the second player won.
+// The objective maximizes the score for the player who moved last.
+func (s *subtractionSearch) Score() mcts.Score[float64] {
+	var x float64
+	if s.pile == 0 {
+		x = 1
+		if s.Scored {
+			x = float64(100 - s.depth)
+		}
+		if s.depth&1 == 0 {
+			x = -x
+		}
+	}
+	objective := func(x float64) float64 { return x }
+	if s.depth&1 == 0 {
+		objective = func(x float64) float64 { return -x }
+	}
+	return mcts.Score[float64]{Counter: x, Objective: objective}
+}
+func (s *subtractionSearch) Interface() mcts.SearchInterface[float64] {
+	si := mcts.SearchInterface[float64]{
+		Root:   s.Root,
+		Select: s.Select,
+		Expand: s.Expand,
+		Score:  s.Score,
+		Topo:   s.Topo,
+	}
+	if s.Topo == mcts.TopoGraph {
+		si.Hash = s.Hash
+	}
+	return SearchInterface(si)
+}
+
+func TestSolver(t *testing.T) {
+	for _, topo := range []mcts.Topo{mcts.TopoDefault, mcts.TopoGraph} {
+		r := rand.New(rand.NewSource(1337))
+		g := &subtractionSearch{Topo: topo, Pile: 7}
+		s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, Solver: true, NumEpisodes: 100000}
+		res := s.Search()
+
+		if res.StopReason != mcts.StopProven {
+			t.Fatalf("TestSolver(%d): got StopReason = %v, want %v", topo, res.StopReason, mcts.StopProven)
+		}
+		e := s.RootEntry.ProvenChild()
+		if e == nil {
+			t.Fatalf("TestSolver(%d): got ProvenChild = nil", topo)
+		}
+		if got := int(e.Action.(banditAction)); got != 1 {
+			t.Errorf("TestSolver(%d): got proven action = %d, want 1", topo, got)
+		}
+		if got, want := e.ProofString(), "proven win in 5"; got != want {
+			t.Errorf("TestSolver(%d): got %q, want %q", topo, got, want)
+		}
+		for _, e := range *s.RootEntry {
+			if int(e.Action.(banditAction)) == 2 && e.Proof == mcts.ProvenWin {
+				t.Errorf("TestSolver(%d): got proven win for losing action 2", topo)
+			}
+		}
+	}
+}
+
+func TestSolverLoss(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &subtractionSearch{Pile: 6}
+	s := mcts.Search[float64]{SearchInterface: g.Interface(), Rand: r, Solver: true, NumEpisodes: 100000}
+	if res := s.Search(); res.StopReason != mcts.StopProven {
+		t.Fatalf("TestSolverLoss(): got StopReason = %v, want %v", res.StopReason, mcts.StopProven)
+	}
+	for _, e := range *s.RootEntry {
+		if e.Proof != mcts.ProvenLoss {
+			t.Errorf("TestSolverLoss(): got Proof = %v for %v, want loss", e.Proof, e.Action)
+		}
+	}
+}
+
+func TestSolverParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &subtractionSearch{Pile: 10}
+	si := g.Interface()
+	si.Clone = func() mcts.SearchInterface[float64] { return (&subtractionSearch{Pile: 10}).Interface() }
+	s := mcts.Search[float64]{SearchInterface: si, Rand: r, Solver: true, NumEpisodes: 100000, NumWorkers: 4}
+	if res := s.Search(); res.StopReason != mcts.StopProven {
+		t.Fatalf("TestSolverParallel(): got StopReason = %v, want %v", res.StopReason, mcts.StopProven)
+	}
+	if e := s.RootEntry.ProvenChild(); e == nil || e.Proof != mcts.ProvenWin || int(e.Action.(banditAction)) != 1 {
+		t.Errorf("TestSolverParallel(): got ProvenChild = %v, want proven win with action 1", e)
+	}
+}
@@ -0,0 +1,163 @@
+package graph
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/wenooij/mcts"
+)
+
+func TestSearchContextStopReason(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelTimeout()
+
+	for _, tc := range []struct {
+		name       string
+		ctx        context.Context
+		search     mcts.Search[float64]
+		wantReason mcts.StopReason
+	}{{
+		name:       "episodes",
+		ctx:        context.Background(),
+		search:     mcts.Search[float64]{NumEpisodes: 10},
+		wantReason: mcts.StopEpisodes,
+	}, {
+		name:       "canceled",
+		ctx:        canceled,
+		search:     mcts.Search[float64]{NumEpisodes: -1},
+		wantReason: mcts.StopCanceled,
+	}, {
+		name:       "time budget",
+		ctx:        context.Background(),
+		search:     mcts.Search[float64]{NumEpisodes: -1, TimeBudget: 10 * time.Millisecond},
+		wantReason: mcts.StopDeadline,
+	}, {
+		// NumEpisodes is unlimited by default with a time limit.
+		name:       "time budget default episodes",
+		ctx:        context.Background(),
+		search:     mcts.Search[float64]{TimeBudget: 20 * time.Millisecond},
+		wantReason: mcts.StopDeadline,
+	}, {
+		name:       "deadline default episodes",
+		ctx:        context.Background(),
+		search:     mcts.Search[float64]{Deadline: time.Now().Add(20 * time.Millisecond)},
+		wantReason: mcts.StopDeadline,
+	}, {
+		name:       "context deadline default episodes",
+		ctx:        timeout,
+		search:     mcts.Search[float64]{},
+		wantReason: mcts.StopDeadline,
+	}, {
+		name:       "max nodes",
+		ctx:        context.Background(),
+		search:     mcts.Search[float64]{NumEpisodes: -1, MaxNodes: 100},
+		wantReason: mcts.StopMaxNodes,
+	}, {
+		name:       "parallel time budget",
+		ctx:        context.Background(),
+		search:     mcts.Search[float64]{NumEpisodes: -1, NumWorkers: 4, TimeBudget: 10 * time.Millisecond},
+		wantReason: mcts.StopDeadline,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1337))
+			s := tc.search
+			s.SearchInterface = (&dummySearch{BranchFactor: 3, MaxDepth: 10, Rand: r}).Interface()
+			s.Rand = r
+			res := s.SearchContext(tc.ctx)
+			if res.StopReason != tc.wantReason {
+				t.Errorf("SearchContext(): got StopReason = %v, want %v", res.StopReason, tc.wantReason)
+			}
+			var numRollouts float64
+			for _, e := range *s.RootEntry {
+				numRollouts += e.NumRollouts
+			}
+			if float64(res.NumEpisodes) != numRollouts {
+				t.Errorf("SearchContext(): got NumEpisodes = %d, want root rollouts %f", res.NumEpisodes, numRollouts)
+			}
+		})
+	}
+}
+
+func TestSearchDefaultEpisodes(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	s := mcts.Search[float64]{SearchInterface: (&dummySearch{BranchFactor: 3, MaxDepth: 10, Rand: r}).Interface(), Rand: r}
+	if res := s.Search(); res.StopReason != mcts.StopEpisodes || res.NumEpisodes != 100 {
+		t.Errorf("TestSearchDefaultEpisodes(): got StopReason = %v after %d episodes, want %v after 100", res.StopReason, res.NumEpisodes, mcts.StopEpisodes)
+	}
+	// The default from the first call must not limit a later call with a time limit.
+	s.TimeBudget = 20 * time.Millisecond
+	if res := s.Search(); res.StopReason != mcts.StopDeadline {
+		t.Errorf("TestSearchDefaultEpisodes(): got StopReason = %v after %d episodes with TimeBudget, want %v", res.StopReason, res.NumEpisodes, mcts.StopDeadline)
+	}
+	if s.NumEpisodes != 0 {
+		t.Errorf("TestSearchDefaultEpisodes(): got NumEpisodes = %d, want 0", s.NumEpisodes)
+	}
+}
+
+func TestEarlyStopSingleAction(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	s := mcts.Search[float64]{
+		SearchInterface:   (&dummySearch{BranchFactor: 1, MaxDepth: 3, Rand: r}).Interface(),
+		Rand:              r,
+		NumEpisodes:       100,
+		EarlyStopInterval: 10,
+	}
+	res := s.Search()
+	if res.StopReason != mcts.StopEarly {
+		t.Errorf("TestEarlyStopSingleAction(): got StopReason = %v, want %v", res.StopReason, mcts.StopEarly)
+	}
+	if res.NumEpisodes != 10 || res.SavedEpisodes != 90 {
+		t.Errorf("TestEarlyStopSingleAction(): got episodes = %d, saved = %d, want 10, 90", res.NumEpisodes, res.SavedEpisodes)
+	}
+}
+
+func TestEarlyStopWidening(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	s := mcts.Search[float64]{
+		SearchInterface:   (&wideSearch{N: 10000, Rand: r}).Interface(),
+		Rand:              r,
+		WideningFactor:    1,
+		NumEpisodes:       1000,
+		EarlyStopInterval: 1,
+	}
+	// The root starts with a single child but more are added as it is widened.
+	if res := s.Search(); res.StopReason == mcts.StopEarly {
+		t.Errorf("TestEarlyStopWidening(): got StopReason = %v after %d episodes, want no early stop while the root is widened", res.StopReason, res.NumEpisodes)
+	}
+}
+
+func TestEarlyStop(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		s := mcts.Search[float64]{
+			SearchInterface:   (&dummySearch{BranchFactor: 3, MaxDepth: 3, Rand: r}).Interface(),
+			Rand:              r,
+			NumEpisodes:       1000,
+			EarlyStopInterval: 10,
+		}
+		res := s.Search()
+		if got := res.NumEpisodes + res.SavedEpisodes; got != 1000 {
+			t.Errorf("TestEarlyStop(%d): got episodes + saved = %d, want 1000", seed, got)
+		}
+		if res.StopReason != mcts.StopEarly {
+			continue
+		}
+		// The most visited root Action must not be overtaken by the saved episodes.
+		var first, second float64
+		for _, e := range *s.RootEntry {
+			switch n := e.NumRollouts; {
+			case n > first:
+				first, second = n, first
+			case n > second:
+				second = n
+			}
+		}
+		if first-second <= float64(res.SavedEpisodes) {
+			t.Errorf("TestEarlyStop(%d): got rollout margin = %f, want > %d saved episodes", seed, first-second, res.SavedEpisodes)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+func TestTreeDoesNotHash(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	d := &dummySearch{BranchFactor: 3, MaxDepth: 4, Rand: r}
+	si := d.Interface()
+	si.Hash = func() uint64 { t.Fatal("TestTreeDoesNotHash(): unexpected call to Hash"); return 0 }
+	s := mcts.Search[float64]{SearchInterface: si, Rand: r, NumEpisodes: 100}
+	s.Search()
+
+	if s.RootEntry == nil || len(*s.RootEntry) != 3 {
+		t.Fatalf("TestTreeDoesNotHash(): expected 3 root children")
+	}
+	var numRollouts float64
+	for _, e := range *s.RootEntry {
+		numRollouts += e.NumRollouts
+	}
+	if numRollouts != 100 {
+		t.Errorf("TestTreeDoesNotHash(): got root rollouts = %f, want 100", numRollouts)
+	}
+}
+
+func TestTopoOverride(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	d := &dummySearch{Topo: mcts.TopoGraph, BranchFactor: 3, MaxDepth: 4, Rand: r}
+	si := d.Interface()
+	// Choose the tree topology after the SearchInterface was created for a graph.
+	si.Topo = mcts.TopoDefault
+	si.Hash = func() uint64 { t.Fatal("TestTopoOverride(): unexpected call to Hash"); return 0 }
+	s := mcts.Search[float64]{SearchInterface: si, Rand: r, NumEpisodes: 100}
+	s.Search()
+
+	// Switching to the graph topology discards the tree.
+	var numHash int
+	s.Topo = mcts.TopoGraph
+	s.Hash = func() uint64 { numHash++; return d.Hash() }
+	s.Search()
+	if numHash == 0 {
+		t.Errorf("TestTopoOverride(): got no calls to Hash with TopoGraph, want > 0")
+	}
+	var numRollouts float64
+	for _, e := range *s.RootEntry {
+		numRollouts += e.NumRollouts
+	}
+	if numRollouts != 100 {
+		t.Errorf("TestTopoOverride(): got root rollouts = %f after changing Topo, want 100", numRollouts)
+	}
+}
@@ -0,0 +1,134 @@
+package graph
+
+import (
+	"math"
+
+	"github.com/wenooij/mcts"
+	"github.com/wenooij/mcts/internal/heap"
+)
+
+// childLimit returns the maximum number of children for a node with the given rollouts
+// or 0 when progressive widening is disabled.
+func (g *graphInterface[T]) childLimit(numRollouts float64) int {
+	if g.widenFactor <= 0 {
+		return 0
+	}
+	return max(1, int(math.Ceil(g.widenFactor*math.Pow(numRollouts, g.widenExponent))))
+}
+
+// expandLimit returns at most limit available actions from ExpandRanked,
+// or all available actions from Expand when limit is 0.
+func expandLimit[T mcts.Counter](s mcts.SearchInterface[T], limit int) []mcts.FrontierAction {
+	if limit > 0 {
+		return s.ExpandRanked(limit)
+	}
+	return s.Expand(0)
+}
+
+// complete returns true if every available Action from e.Dst is in the search structure.
+//
+// With filterAvailable, new Actions may be found in any episode so no node is complete.
+func (g *graphInterface[T]) complete(e *mcts.Edge[T]) bool {
+	return !g.filterAvailable && (g.widenFactor <= 0 || e.Expanded)
+}
+
+// rootComplete returns true if every available Action from the root is in the search structure.
+//
+// Children of simultaneous move nodes are added as each JointAction is chosen.
+func (g *graphInterface[T]) rootComplete() bool {
+	if _, joint := g.joint[g.RootEdge.Dst]; joint {
+		return false
+	}
+	return g.complete(g.RootEdge)
+}
+
+// widen adds children to the current node when its child limit has grown.
+//
+// precondition: the current node has been expanded.
+func (g *graphInterface[T]) widen(s mcts.SearchInterface[T]) {
+	parent := g.ForwardPath[len(g.ForwardPath)-1]
+	n := parent.Dst
+	limit := g.childLimit(parent.NumRollouts)
+	if parent.Expanded || len(*n) >= limit {
+		return
+	}
+	actions := s.ExpandRanked(limit)
+	if len(actions) < limit {
+		parent.Expanded = true
+	}
+	applyPriors(s, actions)
+	if g.addChildren(n, actions) == 0 {
+		return
+	}
+	if g.fpu != nil {
+		g.updatePriorities(*n, parent.NumRollouts, g.exploreFactor)
+	}
+	// New children were appended at the end of the heap.
+	heap.Init(*n)
+}
+
+// addChildren adds Edges to n for actions which are not already in n and renormalizes
+// the prior weights of n. addChildren returns the number of Edges added.
+//
+// Existing Edges returned again by Expand determine the scale of the existing weights.
+// Otherwise existing Edges keep the weight of as many average new Edges.
+func (g *graphInterface[T]) addChildren(n *mcts.EdgeList[T], actions []mcts.FrontierAction) int {
+	numOld := len(*n)
+	var rawOld, normOld float64
+	if numOld > 0 {
+		index := make(map[string]*mcts.Edge[T], numOld)
+		for _, e := range *n {
+			index[e.Action.String()] = e
+		}
+		fresh := actions[:0]
+		for _, a := range actions {
+			if e, ok := index[a.Action.String()]; ok {
+				rawOld += priorWeight(a)
+				normOld += e.PriorWeight
+				continue
+			}
+			fresh = append(fresh, a)
+		}
+		actions = fresh
+	}
+	if len(actions) == 0 {
+		return 0
+	}
+	g.arena.grow(n, len(actions))
+	edges := g.arena.newEdges(len(actions))
+	var rawNew float64
+	for i, a := range actions {
+		// Dst will be filled in on the next Select.
+		// We call Hash after the next Select.
+		edge := &edges[i]
+		*edge = mcts.Edge[T]{Src: n, Dst: nil, Node: makeNode[T](a)}
+		*n = append(*n, edge)
+		// Sum predictor weights to later normalize.
+		rawNew += edge.PriorWeight
+	}
+	g.NumNodes += len(actions)
+	// Scale the normalized weights of existing Edges to raw weights.
+	var sumOld float64
+	for _, e := range (*n)[:numOld] {
+		sumOld += e.PriorWeight
+	}
+	scale := 1.0
+	switch {
+	case normOld > 0:
+		scale = rawOld / normOld
+	case sumOld > 0:
+		scale = float64(numOld) * rawNew / float64(len(actions)) / sumOld
+	}
+	totalWeight := rawNew + scale*sumOld
+	if totalWeight == 0 {
+		panic("expand: got totalWeight = 0")
+	}
+	// Normalize the weights.
+	for i, e := range *n {
+		if i < numOld {
+			e.PriorWeight *= scale
+		}
+		e.PriorWeight /= totalWeight
+	}
+	return len(actions)
+}
@@ -0,0 +1,172 @@
+package graph
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/wenooij/mcts"
+)
+
+// wideSearch is a single step search over N Bernoulli arms.
+// ExpandRanked(n) returns the first n arms where lower arms pay more and have higher prior weight.
+type wideSearch struct {
+	N     int
+	arm   int
+	limit []int
+	Rand  *rand.Rand
+}
+
+func (s *wideSearch) Root() { s.arm = -1 }
+func (s *wideSearch) Select(a mcts.Action) bool {
+	s.arm = int(a.(banditAction))
+	return true
+}
+func (s *wideSearch) Expand(n int) []mcts.FrontierAction {
+	if s.arm >= 0 {
+		return nil
+	}
+	s.limit = append(s.limit, n)
+	if n <= 0 || n > s.N {
+		n = s.N
+	}
+	actions := make([]mcts.FrontierAction, n)
+	for i := range actions {
+		actions[i] = mcts.FrontierAction{Action: banditAction(i), Weight: float64(s.N - i)}
+	}
+	return actions
+}
+func (s *wideSearch) Score() mcts.Score[float64] {
+	var x float64
+	if s.arm >= 0 && s.Rand.Float64() < 1-float64(s.arm)/float64(s.N) {
+		x = 1
+	}
+	return mcts.Score[float64]{Counter: x, Objective: func(x float64) float64 { return x }}
+}
+func (s *wideSearch) Interface() mcts.SearchInterface[float64] {
+	return SearchInterface(mcts.SearchInterface[float64]{
+		Root:         s.Root,
+		Select:       s.Select,
+		Expand:       s.Expand,
+		ExpandRanked: s.Expand,
+		Score:        s.Score,
+	})
+}
+
+func TestProgressiveWidening(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	w := &wideSearch{N: 10000, Rand: r}
+	s := mcts.Search[float64]{SearchInterface: w.Interface(), Rand: r, WideningFactor: 2, NumEpisodes: 1000}
+	s.Search()
+
+	// The root was visited 1000 times so it may have at most ceil(2 * 1000^0.5) = 64 children.
+	if n := len(*s.RootEntry); n > 64 || n < 32 {
+		t.Errorf("TestProgressiveWidening(): got %d root children, want between 32 and 64", n)
+	}
+	for i, n := range w.limit {
+		if n <= 0 {
+			t.Fatalf("TestProgressiveWidening(): got Expand(%d), want n > 0", n)
+		}
+		if i > 0 && n <= w.limit[i-1] {
+			t.Errorf("TestProgressiveWidening(): got Expand(%d) after Expand(%d), want increasing n", n, w.limit[i-1])
+		}
+	}
+	var totalWeight float64
+	for _, e := range *s.RootEntry {
+		totalWeight += e.PriorWeight
+	}
+	if math.Abs(totalWeight-1) > 1e-9 {
+		t.Errorf("TestProgressiveWidening(): got total prior weight = %f, want 1", totalWeight)
+	}
+	// Weights keep their raw proportions after renormalization.
+	raw := func(e *mcts.Edge[float64]) float64 { return float64(w.N - int(e.Action.(banditAction))) }
+	ratio := (*s.RootEntry)[0].PriorWeight / raw((*s.RootEntry)[0])
+	for _, e := range *s.RootEntry {
+		if got := e.PriorWeight / raw(e); math.Abs(got-ratio) > 1e-12 {
+			t.Fatalf("TestProgressiveWidening(): got weight ratio = %g for %v, want %g", got, e.Action, ratio)
+		}
+	}
+	checkHeap(t, *s.RootEntry)
+}
+
+func TestProgressiveWideningComplete(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	w := &wideSearch{N: 5, Rand: r}
+	s := mcts.Search[float64]{SearchInterface: w.Interface(), Rand: r, WideningFactor: 1, NumEpisodes: 100}
+	s.Search()
+
+	if n := len(*s.RootEntry); n != 5 {
+		t.Fatalf("TestProgressiveWideningComplete(): got %d root children, want 5", n)
+	}
+	// Expand is no longer called once it returns fewer actions than the limit.
+	if n := len(w.limit); n != 6 {
+		t.Errorf("TestProgressiveWideningComplete(): got %d calls to Expand at the root, want 6", n)
+	}
+}
+
+func TestProgressiveWideningSolver(t *testing.T) {
+	for _, bounds := range []bool{false, true} {
+		r := rand.New(rand.NewSource(1337))
+		g := &subtractionSearch{Pile: 7, Scored: bounds}
+		si := g.Interface()
+		si.ExpandRanked = si.Expand
+		s := mcts.Search[float64]{SearchInterface: si, Rand: r, Solver: true, ScoreBounds: bounds, WideningFactor: 1, NumEpisodes: 100000}
+		if res := s.Search(); res.StopReason != mcts.StopProven {
+			t.Fatalf("TestProgressiveWideningSolver(%v): got StopReason = %v, want %v", bounds, res.StopReason, mcts.StopProven)
+		}
+		e := s.RootEntry.ProvenChild()
+		if bounds {
+			e = s.RootEntry.BoundedChild()
+		}
+		if e == nil || int(e.Action.(banditAction)) != 1 {
+			t.Errorf("TestProgressiveWideningSolver(%v): got best child %v, want action 1", bounds, e)
+		}
+	}
+}
+
+func TestProgressiveWideningRollout(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	g := &walkSearch{MaxDepth: 20}
+	si := g.Interface()
+	si.RolloutInterface = mcts.RolloutInterface[float64]{}
+	expand := si.Expand
+	si.Expand = func(n int) []mcts.FrontierAction {
+		if n != 1 {
+			t.Errorf("TestProgressiveWideningRollout(): got Expand(%d), want Expand(1) only in rollouts", n)
+		}
+		return expand(n)
+	}
+	var numRanked int
+	si.ExpandRanked = func(n int) []mcts.FrontierAction {
+		numRanked++
+		// Rank stepping down first so that rollouts using ExpandRanked would only step down.
+		actions := expand(0)
+		slices.Reverse(actions)
+		return actions[:min(n, len(actions))]
+	}
+	s := mcts.Search[float64]{SearchInterface: si, Rand: r, WideningFactor: 1, NumEpisodes: 500}
+	s.Search()
+
+	if g.numRolloutSteps == 0 {
+		t.Fatalf("TestProgressiveWideningRollout(): got 0 rollout steps, want > 0")
+	}
+	// Every node in the search structure is first expanded with ExpandRanked.
+	if want := numExpanded(*s.RootEntry) + 1; numRanked < want {
+		t.Errorf("TestProgressiveWideningRollout(): got %d calls to ExpandRanked, want at least %d", numRanked, want)
+	}
+	if got := mostVisited(*s.RootEntry).Action.(banditAction); got != 0 {
+		t.Errorf("TestProgressiveWideningRollout(): got best action %v, want 0", got)
+	}
+}
+
+func TestProgressiveWideningRequiresExpandRanked(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("TestProgressiveWideningRequiresExpandRanked(): want panic")
+		}
+	}()
+	si := (&walkSearch{MaxDepth: 20}).Interface()
+	s := mcts.Search[float64]{SearchInterface: si, WideningFactor: 1}
+	s.Init()
+}
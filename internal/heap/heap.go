@@ -4,6 +4,30 @@ import "github.com/wenooij/mcts"
 
 func Swap[T mcts.Counter](h []*mcts.Edge[T], i, j int) { h[i], h[j] = h[j], h[i] }
 
+func Init[T mcts.Counter](h []*mcts.Edge[T]) {
+	n := len(h)
+	for i := n/2 - 1; i >= 0; i-- {
+		Down(h, i, n)
+	}
+}
+
+func Fix[T mcts.Counter](h []*mcts.Edge[T], i int) {
+	if !Down(h, i, len(h)) {
+		Up(h, i)
+	}
+}
+
+func Up[T mcts.Counter](h []*mcts.Edge[T], j int) {
+	for {
+		i := (j - 1) / 2 // parent
+		if i == j || h[j].Priority >= h[i].Priority {
+			break
+		}
+		Swap(h, i, j)
+		j = i
+	}
+}
+
 func Down[T mcts.Counter](h []*mcts.Edge[T], i0 int, n int) bool {
 	i := i0
 	for {
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Gamma samples from the Gamma distribution with the given shape and unit scale.
+//
+// Gamma uses the method of <Marsaglia, George, and Wai Wan Tsang. "A simple method
+// for generating gamma variables." (2000)>.
+func Gamma(r *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		// Boost the shape and scale the result by U^(1/shape).
+		return Gamma(r, shape+1) * math.Pow(r.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := r.Float64()
+		if u < 1-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
+
+// Beta samples from the Beta distribution with parameters a and b.
+func Beta(r *rand.Rand, a, b float64) float64 {
+	x := Gamma(r, a)
+	return x / (x + Gamma(r, b))
+}
@@ -0,0 +1,217 @@
+package mcts
+
+import (
+	"math"
+	"strings"
+)
+
+// JointAction is the Action of a simultaneous move node with one Action for each player.
+type JointAction []Action
+
+// String returns the Actions of each player such as "(rock,paper)".
+func (a JointAction) String() string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for i, x := range a {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(x.String())
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// PlayerChoice is the set of Actions available to one player at a simultaneous move node.
+type PlayerChoice[T Counter] struct {
+	// Actions available to the player.
+	// FrontierAction.Weight is used as the prior weight of the Action.
+	Actions []FrontierAction
+
+	// Objective returns the value of counters to the player.
+	Objective func(T) float64
+}
+
+// Marginal holds the statistics of one player's Action at a simultaneous move node.
+type Marginal struct {
+	Action      Action
+	PriorWeight float64
+
+	// Score is the sum of rollout values to the player when Action was chosen.
+	// NumRollouts is the number of rollouts in which Action was chosen.
+	Score       float64
+	NumRollouts float64
+
+	// Estimate is the running estimate kept by the JointPolicy.
+	// EXP3 keeps the cumulative estimated reward and RegretMatching keeps the cumulative regret.
+	Estimate float64
+}
+
+// PlayerStats holds the Marginals of one player at a simultaneous move node.
+type PlayerStats[T Counter] struct {
+	Objective func(T) float64
+	Marginals []Marginal
+}
+
+// Strategy returns the mixed strategy recommended for the player.
+//
+// The probability of each Marginal is its share of rollouts. Under every JointPolicy
+// this is the empirical average strategy played during search.
+// If there are no rollouts, the strategy is uniform.
+func (p PlayerStats[T]) Strategy() []float64 {
+	strategy := make([]float64, len(p.Marginals))
+	var total float64
+	for _, m := range p.Marginals {
+		total += m.NumRollouts
+	}
+	for i, m := range p.Marginals {
+		if total == 0 {
+			strategy[i] = 1 / float64(len(p.Marginals))
+			continue
+		}
+		strategy[i] = m.NumRollouts / total
+	}
+	return strategy
+}
+
+// JointPolicy selects the Action of each player independently at simultaneous move nodes.
+//
+// Values passed to a JointPolicy are assumed to be normalized to the interval [-1, +1].
+type JointPolicy interface {
+	// Probabilities sets p[i] to the probability of choosing ms[i].
+	//
+	// precondition: len(p) == len(ms).
+	Probabilities(p []float64, ms []Marginal, exploreFactor float64)
+
+	// Update is called after ms[i] was chosen with probability p and the rollout
+	// scored the given mean value to the player. Score and NumRollouts of ms[i]
+	// are already updated.
+	Update(ms []Marginal, i int, p, value float64)
+}
+
+// DecoupledUCT chooses the Action with the highest value under Policy for each player
+// as though each player were searching alone.
+//
+// Unvisited Actions are chosen first and ties are broken at random. Nil Policy uses UCB1.
+type DecoupledUCT struct {
+	Policy SelectionPolicy
+}
+
+func (d DecoupledUCT) Probabilities(p []float64, ms []Marginal, exploreFactor float64) {
+	policy := d.Policy
+	if policy == nil {
+		policy = UCB1{}
+	}
+	var numRollouts float64
+	for _, m := range ms {
+		numRollouts += m.NumRollouts
+	}
+	bestValue := math.Inf(-1)
+	for i, m := range ms {
+		p[i] = math.Inf(1)
+		if m.NumRollouts > 0 {
+			p[i] = policy.Value(SelectionStats{
+				Score:             m.Score,
+				NumRollouts:       m.NumRollouts,
+				PriorWeight:       m.PriorWeight,
+				NumParentRollouts: numRollouts,
+				ExploreFactor:     exploreFactor,
+			})
+		}
+		bestValue = max(bestValue, p[i])
+	}
+	// Ties are broken uniformly at random so that players do not cycle in lockstep.
+	var numBest float64
+	for i := range p {
+		if p[i] == bestValue {
+			p[i] = 1
+			numBest++
+		} else {
+			p[i] = 0
+		}
+	}
+	for i := range p {
+		p[i] /= numBest
+	}
+}
+
+func (DecoupledUCT) Update([]Marginal, int, float64, float64) {}
+
+// DefaultJointGamma is the default exploration rate of EXP3 and RegretMatching.
+const DefaultJointGamma = 0.1
+
+// EXP3 chooses Actions with exponential weights on importance-weighted rewards
+// mixed with uniform exploration at rate Gamma.
+//
+//	P(i) = (1 - Gamma) * exp(Gamma/K * G(i)) / sum_j exp(Gamma/K * G(j)) + Gamma/K.
+//
+// Where G(i) is the cumulative estimated reward of Action i and K is the number of Actions.
+// Zero Gamma uses DefaultJointGamma.
+type EXP3 struct {
+	Gamma float64
+}
+
+// jointGamma returns gamma or DefaultJointGamma if gamma is zero.
+func jointGamma(gamma float64) float64 {
+	if gamma == 0 {
+		return DefaultJointGamma
+	}
+	return gamma
+}
+
+func (e EXP3) Probabilities(p []float64, ms []Marginal, _ float64) {
+	gamma := jointGamma(e.Gamma)
+	k := float64(len(ms))
+	eta := gamma / k
+	// Subtract the max estimate to keep the weights in range.
+	maxEstimate := math.Inf(-1)
+	for _, m := range ms {
+		maxEstimate = max(maxEstimate, m.Estimate)
+	}
+	var sum float64
+	for i, m := range ms {
+		p[i] = math.Exp(eta * (m.Estimate - maxEstimate))
+		sum += p[i]
+	}
+	for i := range p {
+		p[i] = (1-gamma)*p[i]/sum + eta
+	}
+}
+
+func (EXP3) Update(ms []Marginal, i int, p, value float64) {
+	// Rewards are rescaled from [-1, +1] to [0, 1].
+	ms[i].Estimate += (value + 1) / 2 / p
+}
+
+// RegretMatching chooses Actions in proportion to their positive cumulative regret
+// mixed with uniform exploration at rate Gamma.
+//
+// Regrets are estimated from the sampled Action only as in outcome sampling.
+// Zero Gamma uses DefaultJointGamma.
+type RegretMatching struct {
+	Gamma float64
+}
+
+func (r RegretMatching) Probabilities(p []float64, ms []Marginal, _ float64) {
+	gamma := jointGamma(r.Gamma)
+	k := float64(len(ms))
+	var sum float64
+	for _, m := range ms {
+		sum += max(0, m.Estimate)
+	}
+	for i, m := range ms {
+		if sum == 0 {
+			p[i] = 1 / k
+			continue
+		}
+		p[i] = (1-gamma)*max(0, m.Estimate)/sum + gamma/k
+	}
+}
+
+func (RegretMatching) Update(ms []Marginal, i int, p, value float64) {
+	// The estimated value of Action j is value/p when j = i and 0 otherwise.
+	for j := range ms {
+		ms[j].Estimate -= value
+	}
+	ms[i].Estimate += value / p
+}
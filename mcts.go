@@ -1,11 +1,35 @@
 // Package mcts provides an implementation of general multi-agent Monte-Carlo tree search (MCTS).
 package mcts
 
+import "math/rand"
+
+// Topo selects the topology of the search structure.
 type Topo int
 
 const (
+	// TopoDefault uses a tree topology.
+	//
+	// Every selected edge creates a new EdgeList and Hash is never called.
+	// This is a good choice when transpositions are rare or hashing is expensive.
 	TopoDefault Topo = iota
+	// TopoGraph uses a graph topology based on Hash.
+	//
+	// States with the same Hash share an EdgeList, so transpositions are merged.
 	TopoGraph
+	// TopoOpenLoop uses an open-loop tree topology for stochastic environments.
+	//
+	// Nodes stand for the sequence of Actions played from Root rather than a state.
+	// Each episode replays the Actions from Root and transitions may be stochastic,
+	// so states are never merged and Hash is never called. Statistics average over
+	// the realized outcomes. Expand(0) is called at every node during selection to find
+	// the Actions available in the realized state and only available children are selected
+	// as with SearchInterface.Determinize. Select may still return false when an Action
+	// is no longer legal.
+	//
+	// WideningFactor and ScoreBounds are ignored with TopoOpenLoop. Solver only proves terminal
+	// states, since Actions may become available in later episodes, and these proofs are only
+	// valid when terminal states do not depend on the realized outcomes.
+	TopoOpenLoop
 )
 
 // Action represents an edge in the a game tree.
@@ -31,6 +55,14 @@ type FrontierAction struct {
 	Weight float64
 }
 
+// Outcome is a possible result of a chance event with its Probability.
+//
+// Probabilities are normalized so they only need to be proportional.
+type Outcome struct {
+	Action      Action
+	Probability float64
+}
+
 // SearchInterface is the minimal interface to MCTS tree state.
 //
 // SearchInterface provides a simple flat interface:
@@ -41,7 +73,7 @@ type FrontierAction struct {
 //
 // The game tree is managed by this package, so it is not required to implement
 // one yourself, so long as Select and Root are relatively inexpensive.
-// Expand will not be called again for nodes in the game tree.
+// Expand will not be called again for nodes in the game tree unless progressive widening is used.
 //
 // MCTS proceeds from the root, down the game tree, selecting the best action
 // at each level, until it reaches a frontier leaf node where a random rollout
@@ -87,6 +119,7 @@ type SearchInterface[T Counter] struct {
 	// RolloutInterface.
 	//
 	// Select should usually return true but may return false to better support chance nodes.
+	// See also Chance and TopoOpenLoop.
 	// An example is when the legality of an Action is dependent on a chance node higher up in
 	// the tree.
 	//
@@ -99,12 +132,30 @@ type SearchInterface[T Counter] struct {
 	// When n <= 0, all available actions are returned.
 	//
 	// Expand is called after the selection phase with n <= 0 to expand the frontier of a leaf node.
+	// With progressive widening, ExpandRanked is called instead.
 	// If Expand returns no actions, the current state is marked as a terminal.
 	//
 	// Expand will be called during rollout with n = 1 if Search does not implement RolloutInterface.
 	// Expand must always eventually return a terminal if using the default rollout strategy.
 	Expand func(n int) []FrontierAction
 
+	// ExpandRanked returns at most n available actions with the most promising actions first
+	// in a consistent order.
+	//
+	// ExpandRanked is required with progressive widening and is called in place of Expand
+	// with increasing n > 0 as visits to a node accumulate. See Search.WideningFactor.
+	// The default rollout still calls Expand(1) so that rollouts remain random.
+	// If ExpandRanked returns no actions, the current state is marked as a terminal.
+	ExpandRanked func(n int) []FrontierAction
+
+	// Priors is an optional method which returns the prior weight of each of the Actions
+	// returned by Expand or ExpandRanked, replacing their Weights.
+	//
+	// Priors is only called when Actions are added to the search structure and never during
+	// rollouts, so an expensive prior such as a policy network does not slow down rollouts.
+	// See FrontierAction for the meaning of prior weights.
+	Priors func(actions []FrontierAction) []float64
+
 	// Score is a record for scorekeeping in search.
 	//
 	// Score will be called on each expanded node and on each terminal state reached.
@@ -123,6 +174,7 @@ type SearchInterface[T Counter] struct {
 
 	// Hash is an optional method returning a 64 bit hash of the current state.
 	//
+	// Hash is only used with TopoGraph.
 	// Hash will use a default implementation using the Action string.
 	//
 	// The default hash implementation does not support transpositions
@@ -131,10 +183,92 @@ type SearchInterface[T Counter] struct {
 	// leading to a higher effective branching factor.
 	Hash func() uint64
 
-	// Topology to use, by default graph.
+	// Chance is an optional method which returns the outcome distribution when the
+	// current state is a chance node, or nil when it is a decision node.
+	//
+	// At chance nodes, the search keeps one child per outcome and samples outcomes by
+	// Probability during selection and rollout. Outcomes are applied with Select.
+	// Expand is not called at chance nodes.
+	//
+	// Rollout scores are backpropagated to every Edge as sampled, so Edge.Score and the
+	// reported scores are sample means which estimate the expected value. Only for
+	// selection, the Edge leading to a chance node is valued by the Probability-weighted
+	// mean of its visited outcomes to reduce the sampling noise.
+	// Solver proofs and ScoreBounds do not propagate through chance nodes.
+	//
+	// Chance is an alternative to sampling outcomes inside Select which merges all
+	// outcomes into a single Edge.
+	Chance func() []Outcome
+
+	// Determinize is an optional method which samples the hidden information of the current state
+	// consistent with what the acting players have observed.
+	//
+	// When Determinize is set, Search uses Information Set MCTS (ISMCTS). Determinize is called
+	// after Root at the start of every episode and the episode is played in the sampled state.
+	// Nodes stand for the acting player's information set rather than the full state, so with
+	// TopoGraph, Hash must identify the information set and not the hidden information.
+	// The tree topology keys nodes by the Actions played which identifies the information
+	// set when every Action is observed by all players.
+	//
+	// Expand(0) is called at every node during selection to find the Actions available in the
+	// determinization and only available children are selected. The SelectionPolicy counts
+	// the times a child was available (Node.NumAvailable) in place of the parent's rollouts.
+	// WideningFactor and ScoreBounds are ignored with Determinize. Solver only proves terminal
+	// states, since Actions may become available in later determinizations, and these proofs
+	// are only valid when terminal states do not depend on hidden information.
+	Determinize func(r *rand.Rand)
+
+	// Simultaneous is an optional method which returns the Actions available to each player
+	// when the current state is a simultaneous move node, or nil when players move in turn.
+	//
+	// At simultaneous move nodes, each player chooses an Action independently using
+	// Search.JointPolicy and the JointAction of all players is applied with Select.
+	// Children are added for each JointAction as it is chosen. Expand is not called at
+	// simultaneous move nodes, and the default rollout chooses uniform random Actions.
+	// The statistics of each player are kept separately using the player's Objective
+	// and the recommended mixed strategies are available from Search.JointStats.
+	// Solver proofs and ScoreBounds do not propagate through simultaneous move nodes.
+	Simultaneous func() []PlayerChoice[T]
+
+	// Player is an optional method which returns the index of the player to act
+	// in the current state.
+	//
+	// Player is required when Search.Reduction is set and is called before an Action
+	// is first selected to choose the objective of its Edge. With an AMAFPolicy,
+	// Player is also called at each decision to credit Actions only to their player.
+	// Player is not called at chance nodes or simultaneous move nodes.
+	Player func() int
+
+	// SetPlayer is an optional method which sets the player to act in the current state.
+	//
+	// SetPlayer is required with Search.Reduction BestReply. It is called to find the
+	// Actions of each opponent with Expand(0) and to apply a PlayerAction with Select,
+	// after which the root player is set to act. Expand should return no actions for
+	// any player in a terminal state.
+	SetPlayer func(player int)
+
+	// Snapshot returns the current state in a form which remains valid after later calls
+	// to Select and Root, such as a copy or an encoded tensor.
+	//
+	// Snapshot is required when Search.BatchEvaluator is set and is called at each leaf
+	// passed to the BatchEvaluator. It is not used otherwise.
+	Snapshot func() any
+
+	// Clone is an optional method returning a SearchInterface for an independent copy of the search state.
+	//
+	// Clone is required when Search.NumWorkers > 1 and is called once for each worker.
+	// The returned SearchInterface must not share mutable state with the original.
+	// Only the search state methods and RolloutInterface are used from the clone.
+	Clone func() SearchInterface[T]
+
+	// Topology to use, by default tree.
+	//
+	// Topo is read by Search.Init, so it may be changed after the SearchInterface is created.
+	// Changing Topo between calls to Search discards the search continuation.
 	Topo Topo
 
-	// InternalInterface custom implementation or leave empty to use a builtin one.
+	// InternalInterface custom implementation.
+	// Use model.MakeSearchInterface to wire a builtin one for the given Topo.
 	InternalInterface[T]
 
 	// Optional Rollout implementation.
@@ -163,4 +297,15 @@ type RolloutInterface[T Counter] struct {
 	//
 	// Backpropagation is skipped when numRollouts is 0.
 	Rollout func() (counters T, numRollouts float64)
+
+	// Evaluate is an optional method which returns a value estimate of the current state
+	// in place of a rollout, such as a heuristic static evaluator or a value network.
+	//
+	// The counters and numRollouts returned by Evaluate are backpropagated as with Rollout.
+	// When Evaluate is set, the default rollout policy plays at most Search.RolloutDepth
+	// Actions before Evaluate is called. Each node is evaluated when it is first expanded
+	// rather than selecting one of its new children. With the default RolloutDepth of 0,
+	// nodes are evaluated without a rollout. Evaluate is never called on terminal states
+	// where Score is used instead. Evaluate takes precedence over Rollout.
+	Evaluate func() (counters T, numRollouts float64)
 }
@@ -1,14 +1,91 @@
 package model
 
 import (
+	"math/rand"
+
 	"github.com/wenooij/mcts"
 	"github.com/wenooij/mcts/internal/graph"
 )
 
+// MakeSearchInterface creates a SearchInterface from the methods implemented by x.
+//
+// The graph topology is used when x implements Hash, otherwise the tree topology is used.
+// Set Topo on the returned SearchInterface to choose another topology, such as the tree
+// topology when hashing is expensive. See also MakeOpenLoopSearchInterface.
+// If x implements Chance() []mcts.Outcome, chance nodes are used.
+// If x implements Determinize(*rand.Rand), Information Set MCTS is used.
+// If x implements Simultaneous() []mcts.PlayerChoice[T], simultaneous move nodes are used.
+// If x implements Player() int, the acting player is available for Search.Reduction.
+// If x implements SetPlayer(int), the turns of the opponents can be merged for mcts.BestReply.
+// If x implements ExpandRanked(int) []mcts.FrontierAction, it is used for progressive widening.
+// If x implements Priors([]mcts.FrontierAction) []float64, it replaces the prior weights from Expand.
+// If x implements Snapshot() any, leaf states are passed to Search.BatchEvaluator.
+// If x implements Rollout() (T, float64) or Evaluate() (T, float64), they are used in place of
+// the default rollout.
+// If x implements Clone() any, the clone is used to create a SearchInterface for each worker.
 func MakeSearchInterface[T mcts.Counter](x any, counter mcts.CounterInterface[T]) mcts.SearchInterface[T] {
-	var hash func() uint64
-	if h, ok := x.(interface{ Hash() uint64 }); ok {
+	return makeSearchInterface(x, counter, false)
+}
+
+// MakeOpenLoopSearchInterface creates a SearchInterface using the open-loop topology
+// from the methods implemented by x.
+//
+// Hash is never used. The other methods are used as in MakeSearchInterface.
+// See mcts.TopoOpenLoop.
+func MakeOpenLoopSearchInterface[T mcts.Counter](x any, counter mcts.CounterInterface[T]) mcts.SearchInterface[T] {
+	return makeSearchInterface(x, counter, true)
+}
+
+func makeSearchInterface[T mcts.Counter](x any, counter mcts.CounterInterface[T], openLoop bool) mcts.SearchInterface[T] {
+	var (
+		hash         func() uint64
+		expandRanked func(int) []mcts.FrontierAction
+		chance       func() []mcts.Outcome
+		determinize  func(*rand.Rand)
+		simultaneous func() []mcts.PlayerChoice[T]
+		player       func() int
+		setPlayer    func(int)
+		snapshot     func() any
+		priors       func([]mcts.FrontierAction) []float64
+		clone        func() mcts.SearchInterface[T]
+		topo         = mcts.TopoDefault
+	)
+	if openLoop {
+		topo = mcts.TopoOpenLoop
+	} else if h, ok := x.(interface{ Hash() uint64 }); ok {
 		hash = h.Hash
+		topo = mcts.TopoGraph
+	}
+	if e, ok := x.(interface {
+		ExpandRanked(int) []mcts.FrontierAction
+	}); ok {
+		expandRanked = e.ExpandRanked
+	}
+	if c, ok := x.(interface{ Chance() []mcts.Outcome }); ok {
+		chance = c.Chance
+	}
+	if d, ok := x.(interface{ Determinize(*rand.Rand) }); ok {
+		determinize = d.Determinize
+	}
+	if m, ok := x.(interface{ Simultaneous() []mcts.PlayerChoice[T] }); ok {
+		simultaneous = m.Simultaneous
+	}
+	if p, ok := x.(interface{ Player() int }); ok {
+		player = p.Player
+	}
+	if p, ok := x.(interface{ SetPlayer(int) }); ok {
+		setPlayer = p.SetPlayer
+	}
+	if m, ok := x.(interface {
+		Priors([]mcts.FrontierAction) []float64
+	}); ok {
+		priors = m.Priors
+	}
+	if m, ok := x.(interface{ Snapshot() any }); ok {
+		snapshot = m.Snapshot
+	}
+	if c, ok := x.(interface{ Clone() any }); ok {
+		clone = func() mcts.SearchInterface[T] { return makeSearchInterface(c.Clone(), counter, openLoop) }
 	}
 	s := mcts.SearchInterface[T]{
 		Root:   x.(interface{ Root() }).Root,
@@ -16,8 +93,18 @@ func MakeSearchInterface[T mcts.Counter](x any, counter mcts.CounterInterface[T]
 		Expand: x.(interface {
 			Expand(int) []mcts.FrontierAction
 		}).Expand,
+		ExpandRanked:     expandRanked,
 		Score:            x.(interface{ Score() mcts.Score[T] }).Score,
+		Priors:           priors,
 		Hash:             hash,
+		Chance:           chance,
+		Determinize:      determinize,
+		Simultaneous:     simultaneous,
+		Player:           player,
+		SetPlayer:        setPlayer,
+		Snapshot:         snapshot,
+		Clone:            clone,
+		Topo:             topo,
 		RolloutInterface: makeRolloutInterface[T](x),
 		CounterInterface: counter,
 	}
@@ -25,9 +112,12 @@ func MakeSearchInterface[T mcts.Counter](x any, counter mcts.CounterInterface[T]
 }
 
 func makeRolloutInterface[T mcts.Counter](x any) mcts.RolloutInterface[T] {
-	ri, ok := x.(interface{ Rollout() (T, float64) })
-	if !ok {
-		return mcts.RolloutInterface[T]{}
+	var ri mcts.RolloutInterface[T]
+	if r, ok := x.(interface{ Rollout() (T, float64) }); ok {
+		ri.Rollout = r.Rollout
+	}
+	if e, ok := x.(interface{ Evaluate() (T, float64) }); ok {
+		ri.Evaluate = e.Evaluate
 	}
-	return mcts.RolloutInterface[T]{Rollout: ri.Rollout}
+	return ri
 }
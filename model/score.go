@@ -42,6 +42,23 @@ func MaximizeNPlayers[T Scalar](n int) []func([]T) float64 {
 	return maximize
 }
 
+// PlayerComponents returns objectives which maximize the score component of each of n players.
+//
+// PlayerComponents is intended for mcts.Search.PlayerObjectives with the MaxN Reduction
+// in games which are not zero-sum. Use MaximizeNPlayers for zero-sum games.
+func PlayerComponents[T Scalar](n int) []func([]T) float64 {
+	components := make([]func([]T) float64, n)
+	for i := range components {
+		components[i] = func(x []T) float64 {
+			if i >= len(x) {
+				return 0
+			}
+			return float64(x[i])
+		}
+	}
+	return components
+}
+
 func MinimizePlayer1[T Scalar](c [2]T) float64 { return -MaximizePlayer1[T](c) }
 func MinimizePlayer2[T Scalar](c [2]T) float64 { return -MaximizePlayer2[T](c) }
 
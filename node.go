@@ -11,6 +11,17 @@ type EdgeList[T Counter] []*Edge[T]
 type Edge[T Counter] struct {
 	Src, Dst *EdgeList[T]
 	Node[T]
+
+	// NumInflight is the number of pending episodes currently searching through this Edge.
+	//
+	// NumInflight is only used when Search.NumWorkers > 1 or when Search.BatchEvaluator
+	// is set with Search.BatchSize > 1.
+	NumInflight int
+
+	// Expanded is set once every available Action from Dst has been added to Dst.
+	//
+	// Expanded is only needed for progressive widening where Dst grows over time.
+	Expanded bool
 }
 
 type Node[T Counter] struct {
@@ -19,10 +30,57 @@ type Node[T Counter] struct {
 	Priority    float64
 	NumRollouts float64
 	PriorWeight float64
+
+	// SquaredScore is the sum of squared rollout scores under Score.Objective.
+	// It is used by selection policies which estimate the variance of scores.
+	SquaredScore float64
+
+	// AMAFScore and NumAMAFRollouts are all-moves-as-first statistics:
+	// the sum of rollout scores and the number of rollouts in which Action was
+	// played at any point after the parent state.
+	// They are only recorded for an AMAFPolicy.
+	AMAFScore       float64
+	NumAMAFRollouts float64
+
+	// Proof is the proven outcome of choosing Action when Search.Solver is set.
+	// ProofCounter is the terminal score reached with best play and ProofDepth is
+	// the number of plies after Action before the terminal state.
+	Proof        Proof
+	ProofDepth   int
+	ProofCounter T
+
+	// Pessimistic and Optimistic bound the value of choosing Action under Score.Objective
+	// when Search.ScoreBounds is set. Bounds are infinite until tightened by solved children.
+	Pessimistic float64
+	Optimistic  float64
+
+	// Probability is the normalized probability of Action when it is an Outcome
+	// of a chance node, or 0 for decisions.
+	Probability float64
+
+	// NumAvailable is the number of episodes in which Action was available when
+	// its parent was selected. It is only counted with SearchInterface.Determinize or TopoOpenLoop.
+	NumAvailable float64
 }
 
 func (e Node[T]) appendString(sb *strings.Builder) {
 	fmt.Fprintf(sb, "[%f] %s (%d)", e.Score.Apply()/float64(e.NumRollouts), e.Action, int64(e.NumRollouts))
+	if e.Probability > 0 {
+		fmt.Fprintf(sb, " p=%.2f", e.Probability)
+	}
+	if e.Proof != Unproven {
+		fmt.Fprintf(sb, " %s", e.ProofString())
+	}
+}
+
+// ProofString formats the Proof of e such as "proven win in 3".
+//
+// The length counts the plies including Action.
+func (e Node[T]) ProofString() string {
+	if e.Proof == Unproven {
+		return e.Proof.String()
+	}
+	return fmt.Sprintf("proven %s in %d", e.Proof, e.ProofDepth+1)
 }
 
 func (e Node[T]) String() string {
@@ -0,0 +1,269 @@
+package mcts
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/wenooij/mcts/internal/model"
+)
+
+// SelectionStats are the statistics of a child Edge used to compute its selection value.
+type SelectionStats struct {
+	// Score is the sum of rollout scores under the Edge's Score.Objective.
+	Score float64
+	// SquaredScore is the sum of squared rollout scores.
+	SquaredScore float64
+	// NumRollouts is the number of rollouts through the Edge.
+	NumRollouts float64
+	// PriorWeight is the normalized prior weight of the Edge.
+	PriorWeight float64
+	// NumParentRollouts is the number of rollouts through the parent Edge.
+	// With SearchInterface.Determinize or TopoOpenLoop, it is the number of times the Edge was available instead.
+	NumParentRollouts float64
+	// ExploreFactor is the ExploreFactor of the Search.
+	ExploreFactor float64
+
+	// AMAFScore and NumAMAFRollouts are the all-moves-as-first statistics of the Edge.
+	// They are only recorded for an AMAFPolicy.
+	AMAFScore       float64
+	NumAMAFRollouts float64
+}
+
+// Mean returns the mean rollout score.
+func (s SelectionStats) Mean() float64 { return s.Score / s.NumRollouts }
+
+// SelectionPolicy computes the value of selecting a child Edge.
+//
+// The child with the highest value is selected. Children which have not been visited
+// are always selected first, so Value is only called when NumRollouts > 0.
+//
+// Values are recomputed for all children of an Edge whenever its rollouts change,
+// so any policy which depends only on SelectionStats is supported.
+type SelectionPolicy interface {
+	Value(SelectionStats) float64
+}
+
+// PUCB is the default SelectionPolicy based on the prior-weighted UCB policy of
+// <Rosin, Christopher D. "Multi-armed bandits with episode context." (2011)>.
+//
+//	Value = (Score + PriorWeight * ExploreFactor * sqrt(NumParentRollouts)) / NumRollouts.
+type PUCB struct{}
+
+func (PUCB) Value(s SelectionStats) float64 {
+	return model.PUCB(s.Score, s.NumRollouts, s.PriorWeight, s.ExploreFactor*math.Sqrt(s.NumParentRollouts))
+}
+
+// UCB1 is the classic UCB1 policy which ignores prior weights.
+//
+//	Value = Mean + ExploreFactor * sqrt(ln(NumParentRollouts) / NumRollouts).
+type UCB1 struct{}
+
+func (UCB1) Value(s SelectionStats) float64 {
+	return s.Mean() + s.ExploreFactor*math.Sqrt(math.Log(s.NumParentRollouts)/s.NumRollouts)
+}
+
+// PUCT is the AlphaZero selection policy.
+//
+//	C = ln((1 + NumParentRollouts + CBase) / CBase) + CInit.
+//	Value = Mean + C * PriorWeight * sqrt(NumParentRollouts) / (1 + NumRollouts).
+type PUCT struct {
+	// CBase controls the growth of exploration with parent rollouts.
+	// Zero uses the AlphaZero value of 19652.
+	CBase float64
+	// CInit is the base exploration constant.
+	// Zero uses ExploreFactor.
+	CInit float64
+}
+
+func (p PUCT) Value(s SelectionStats) float64 {
+	cBase, cInit := p.CBase, p.CInit
+	if cBase == 0 {
+		cBase = 19652
+	}
+	if cInit == 0 {
+		cInit = s.ExploreFactor
+	}
+	c := math.Log((1+s.NumParentRollouts+cBase)/cBase) + cInit
+	return s.Mean() + c*s.PriorWeight*math.Sqrt(s.NumParentRollouts)/(1+s.NumRollouts)
+}
+
+// UCB1Tuned is the UCB1-Tuned policy which bounds exploration using the variance of scores.
+//
+// UCB1Tuned assumes scores in the interval [0, 1] and ignores ExploreFactor.
+//
+//	V = SquaredScore / NumRollouts - Mean^2 + sqrt(2 * ln(NumParentRollouts) / NumRollouts).
+//	Value = Mean + sqrt(ln(NumParentRollouts) / NumRollouts * min(1/4, V)).
+type UCB1Tuned struct{}
+
+func (UCB1Tuned) Value(s SelectionStats) float64 {
+	mean := s.Mean()
+	logN := math.Log(s.NumParentRollouts)
+	v := s.SquaredScore/s.NumRollouts - mean*mean + math.Sqrt(2*logN/s.NumRollouts)
+	return mean + math.Sqrt(logN/s.NumRollouts*min(0.25, v))
+}
+
+// SamplingPolicy is a SelectionPolicy which samples values at selection time.
+//
+// When the SelectionPolicy is a SamplingPolicy, Sample is called on each visited child
+// and the child with the highest sample is selected. Value is still used to maintain
+// the order of children.
+type SamplingPolicy interface {
+	SelectionPolicy
+	Sample(r *rand.Rand, s SelectionStats) float64
+}
+
+// Posterior selects the posterior distribution used by Thompson.
+type Posterior int
+
+const (
+	// PosteriorBeta uses a Beta(1+wins, 1+losses) posterior over win rate.
+	// Rollout scores must be in the interval [Thompson.MinScore, Thompson.MaxScore].
+	PosteriorBeta Posterior = iota
+	// PosteriorNormalGamma uses a Normal-Gamma posterior over the mean of
+	// continuous scores with an uninformative prior for the mean and a
+	// Gamma(1, 1) prior for the precision.
+	PosteriorNormalGamma
+)
+
+// Thompson is a SamplingPolicy which selects children by Thompson sampling.
+//
+// Thompson ignores ExploreFactor and PriorWeight.
+// Value returns the posterior mean.
+type Thompson struct {
+	Posterior Posterior
+
+	// MinScore and MaxScore are the scores of a lost and a won rollout used by PosteriorBeta.
+	// Scores in between count as partial wins.
+	// Zero values use the interval [-1, +1] of the win/loss objectives in the model package.
+	MinScore, MaxScore float64
+}
+
+func (p Thompson) Value(s SelectionStats) float64 {
+	if p.Posterior == PosteriorNormalGamma {
+		return s.Mean()
+	}
+	a, b := p.beta(s)
+	return a / (a + b)
+}
+
+func (p Thompson) Sample(r *rand.Rand, s SelectionStats) float64 {
+	if p.Posterior == PosteriorNormalGamma {
+		mean := s.Mean()
+		ss := max(0, s.SquaredScore-s.NumRollouts*mean*mean)
+		alpha := 1 + s.NumRollouts/2
+		beta := 1 + ss/2
+		precision := model.Gamma(r, alpha) / beta
+		return mean + r.NormFloat64()/math.Sqrt(s.NumRollouts*precision)
+	}
+	a, b := p.beta(s)
+	return model.Beta(r, a, b)
+}
+
+func (p Thompson) beta(s SelectionStats) (a, b float64) {
+	lo, hi := p.MinScore, p.MaxScore
+	if lo == 0 && hi == 0 {
+		lo, hi = -1, 1
+	}
+	// Map the summed score of the rollouts to the number of wins.
+	wins := (s.Score - lo*s.NumRollouts) / (hi - lo)
+	wins = min(max(0, wins), s.NumRollouts)
+	return 1 + wins, 1 + s.NumRollouts - wins
+}
+
+// AMAFPolicy is a SelectionPolicy which uses all-moves-as-first statistics.
+//
+// The search only records AMAF statistics and rollout traces when the
+// SelectionPolicy is an AMAFPolicy.
+type AMAFPolicy interface {
+	SelectionPolicy
+	AMAF()
+}
+
+// RAVE is an AMAFPolicy implementing Rapid Action Value Estimation.
+//
+// RAVE replaces the mean score in the Value of Policy with a blend of the mean
+// and the AMAF mean:
+//
+//	Mean' = (1 - Beta) * Mean + Beta * AMAFMean.
+//
+// AMAF statistics are updated for every child whose Action is played later in
+// the same episode by the same player, including actions from the default rollout.
+// The player is given by SearchInterface.Player or otherwise alternates at each
+// decision, so single-agent searches should set Player.
+// Custom Rollout implementations do not record actions.
+type RAVE struct {
+	// Policy is the underlying SelectionPolicy.
+	// Nil uses PUCB.
+	Policy SelectionPolicy
+
+	// Beta is the schedule weighting the AMAF mean as a function of the rollout counts.
+	// Beta should decrease from 1 toward 0 as numRollouts grows.
+	// Nil uses RAVEEquivalence(DefaultRAVEEquivalence).
+	Beta func(numRollouts, numAMAFRollouts float64) float64
+}
+
+// DefaultRAVEEquivalence is the equivalence parameter of the default RAVE schedule.
+const DefaultRAVEEquivalence = 1000
+
+// RAVEEquivalence returns the hand-selected schedule of <Gelly, Sylvain, and David Silver.
+// "Combining online and offline knowledge in UCT." (2007)>.
+//
+//	Beta = sqrt(k / (3 * numRollouts + k)).
+//
+// k is the number of rollouts at which the mean and AMAF mean are weighted equally.
+func RAVEEquivalence(k float64) func(numRollouts, numAMAFRollouts float64) float64 {
+	return func(numRollouts, _ float64) float64 { return math.Sqrt(k / (3*numRollouts + k)) }
+}
+
+// RAVEBias returns the minimum MSE schedule of <Gelly, Sylvain, and David Silver.
+// "Monte-Carlo tree search and rapid action value estimation in computer Go." (2011)>.
+//
+//	Beta = numAMAFRollouts / (numRollouts + numAMAFRollouts + 4 * b^2 * numRollouts * numAMAFRollouts).
+//
+// b is the estimated bias of AMAF values.
+func RAVEBias(b float64) func(numRollouts, numAMAFRollouts float64) float64 {
+	return func(n, amafN float64) float64 {
+		if amafN == 0 {
+			return 0
+		}
+		return amafN / (n + amafN + 4*b*b*n*amafN)
+	}
+}
+
+func (p RAVE) Value(s SelectionStats) float64 {
+	policy := p.Policy
+	if policy == nil {
+		policy = PUCB{}
+	}
+	v := policy.Value(s)
+	if s.NumAMAFRollouts == 0 {
+		return v
+	}
+	betaFn := p.Beta
+	if betaFn == nil {
+		betaFn = RAVEEquivalence(DefaultRAVEEquivalence)
+	}
+	beta := betaFn(s.NumRollouts, s.NumAMAFRollouts)
+	mean := s.Mean()
+	return v - mean + (1-beta)*mean + beta*s.AMAFScore/s.NumAMAFRollouts
+}
+
+func (RAVE) AMAF() {}
+
+// FirstPlayUrgency computes the value of unvisited children.
+// See Search.FirstPlayUrgency.
+type FirstPlayUrgency interface {
+	// Urgency returns the value of unvisited children given the parent value.
+	// The parent value is the mean score of visited children or 0 if there are none.
+	Urgency(parentValue float64) float64
+}
+
+// FPUConstant is a FirstPlayUrgency with a constant value.
+type FPUConstant float64
+
+func (c FPUConstant) Urgency(float64) float64 { return float64(c) }
+
+// FPUReduction is a FirstPlayUrgency which reduces the parent value by a constant.
+type FPUReduction float64
+
+func (r FPUReduction) Urgency(parentValue float64) float64 { return parentValue - float64(r) }
@@ -0,0 +1,45 @@
+package mcts
+
+import (
+	"context"
+	"time"
+)
+
+// Ponderer is a handle to a Search running in the background.
+type Ponderer[T Counter] struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	res    SearchResult
+}
+
+// Ponder searches from the current root in the background until Stop is called or ctx is done.
+//
+// Pondering makes use of idle time while the opponent is thinking. When the opponent's
+// move arrives, call Stop, reset SearchInterface.Root to the new state, and call
+// AdvanceRoot to keep the subtree for the move. The search then continues from the
+// subtree on the next call to Search.
+//
+// NumEpisodes, TimeBudget, Deadline, and EarlyStopInterval are ignored while pondering
+// and restored before Stop returns. MaxNodes is still respected.
+// The Search must not be used until Stop returns.
+func (s *Search[T]) Ponder(ctx context.Context) *Ponderer[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Ponderer[T]{cancel: cancel, done: make(chan struct{})}
+	numEpisodes, timeBudget, deadline, earlyStopInterval := s.NumEpisodes, s.TimeBudget, s.Deadline, s.EarlyStopInterval
+	s.NumEpisodes, s.TimeBudget, s.Deadline, s.EarlyStopInterval = -1, 0, time.Time{}, 0
+	go func() {
+		defer close(p.done)
+		p.res = s.SearchContext(ctx)
+		s.NumEpisodes, s.TimeBudget, s.Deadline, s.EarlyStopInterval = numEpisodes, timeBudget, deadline, earlyStopInterval
+	}()
+	return p
+}
+
+// Stop stops pondering and returns the result of the background Search.
+//
+// Stop may be called multiple times.
+func (p *Ponderer[T]) Stop() SearchResult {
+	p.cancel()
+	<-p.done
+	return p.res
+}
@@ -0,0 +1,101 @@
+package mcts
+
+// Proof is the proven outcome of choosing an Edge from the perspective of the player choosing it.
+//
+// Proofs are only recorded when Search.Solver is set.
+type Proof int8
+
+const (
+	Unproven   Proof = iota // Unproven indicates the outcome of the Edge is not known.
+	ProvenLoss              // ProvenLoss indicates the Edge leads to a loss with best play.
+	ProvenDraw              // ProvenDraw indicates the Edge leads to a draw with best play.
+	ProvenWin               // ProvenWin indicates the Edge leads to a win with best play.
+)
+
+func (p Proof) String() string {
+	switch p {
+	case Unproven:
+		return "unproven"
+	case ProvenLoss:
+		return "loss"
+	case ProvenDraw:
+		return "draw"
+	case ProvenWin:
+		return "win"
+	default:
+		return "unknown"
+	}
+}
+
+// MakeProof classifies the terminal score x under an Edge's objective.
+//
+// Positive scores are wins, negative scores are losses, and zero is a draw,
+// as with the zero-sum objectives in the model package.
+func MakeProof(x float64) Proof {
+	switch {
+	case x > 0:
+		return ProvenWin
+	case x < 0:
+		return ProvenLoss
+	default:
+		return ProvenDraw
+	}
+}
+
+// BoundedChild returns the child whose Pessimistic bound is at least the Optimistic bound
+// of every other child, if any. Such a child is proven to be the best choice.
+//
+// BoundedChild returns nil if no child is proven to be best.
+func (es EdgeList[T]) BoundedChild() *Edge[T] {
+	if len(es) == 0 {
+		return nil
+	}
+	best := es[0]
+	for _, e := range es[1:] {
+		if e.Pessimistic > best.Pessimistic {
+			best = e
+		}
+	}
+	for _, e := range es {
+		if e != best && e.Optimistic > best.Pessimistic {
+			return nil
+		}
+	}
+	return best
+}
+
+// ProvenChild returns the child chosen with best play if the outcome of es is proven.
+//
+// The outcome of es is proven when any child is a ProvenWin or when all children are proven.
+// The shortest win is preferred, followed by any draw, followed by the longest loss.
+// ProvenChild returns nil if the outcome of es is not proven.
+func (es EdgeList[T]) ProvenChild() *Edge[T] {
+	var win, draw, loss *Edge[T]
+	proven := len(es) > 0
+	for _, e := range es {
+		switch e.Proof {
+		case ProvenWin:
+			if win == nil || e.ProofDepth < win.ProofDepth {
+				win = e
+			}
+		case ProvenDraw:
+			draw = e
+		case ProvenLoss:
+			if loss == nil || e.ProofDepth > loss.ProofDepth {
+				loss = e
+			}
+		default:
+			proven = false
+		}
+	}
+	switch {
+	case win != nil:
+		return win
+	case !proven:
+		return nil
+	case draw != nil:
+		return draw
+	default:
+		return loss
+	}
+}
@@ -0,0 +1,55 @@
+package mcts
+
+import "strconv"
+
+// Reduction selects how the choices of each player are valued in N-player games.
+//
+// The acting player at each state is found using SearchInterface.Player and each Edge
+// is valued by the objective of its acting player under the Reduction. The objectives
+// of the players are given by Search.PlayerObjectives.
+type Reduction int
+
+const (
+	// ReductionNone values each Edge by the Objective returned by Score.
+	ReductionNone Reduction = iota
+	// MaxN values each Edge by the objective of the acting player,
+	// so every player maximizes their own component of the counters.
+	MaxN
+	// Paranoid values the Edges of the root player by the objective of the root player
+	// and the Edges of every other player by its negation, as if all opponents
+	// were in a coalition against the root player.
+	Paranoid
+	// BestReply uses the objectives of Paranoid for Best-Reply Search where only the
+	// opponent with the best reply moves between the turns of the root player.
+	//
+	// The turns of the opponents are merged into a single node whose children are the
+	// Actions of every opponent from the current state as PlayerActions. After the
+	// PlayerAction is applied, the root player moves again. SearchInterface.SetPlayer
+	// is required to find and apply the Actions of each opponent.
+	// Priors is called with the PlayerActions and rollouts play the game normally.
+	BestReply
+)
+
+func (r Reduction) String() string {
+	switch r {
+	case ReductionNone:
+		return "none"
+	case MaxN:
+		return "maxn"
+	case Paranoid:
+		return "paranoid"
+	case BestReply:
+		return "best-reply"
+	default:
+		return "unknown"
+	}
+}
+
+// PlayerAction is an Action of one opponent at a merged turn of the opponents under BestReply.
+type PlayerAction struct {
+	Player int
+	Action Action
+}
+
+// String returns the player and Action such as "2:e4".
+func (a PlayerAction) String() string { return strconv.Itoa(a.Player) + ":" + a.Action.String() }
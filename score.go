@@ -27,6 +27,8 @@ type CounterInterface[T Counter] struct {
 	// For common values of T this will be populated automatically.
 	// The common values of T are: float32, float64, [2]float64, []float64, int, and int64.
 	// Custom counters or those in the model package need to be supplied manually.
+	//
+	// Add must support x being the zero value of T.
 	Add func(x *T, y T)
 }
 
@@ -58,34 +60,28 @@ func patchBuiltinAdd[T Counter](c *CounterInterface[T]) {
 		f := func(c1 *[2]float64, c2 [2]float64) { c1[0] += c2[0]; c1[1] += c2[1] }
 		c.Add = *(*func(*T, T))(unsafe.Pointer(&f))
 	case []int:
-		f := func(c1 *[]int, c2 []int) {
-			for i, v := range c2 {
-				(*c1)[i] += v
-			}
-		}
+		f := addSlice[int]
 		c.Add = *(*func(*T, T))(unsafe.Pointer(&f))
 	case []int64:
-		f := func(c1 *[]int64, c2 []int64) {
-			for i, v := range c2 {
-				(*c1)[i] += v
-			}
-		}
+		f := addSlice[int64]
 		c.Add = *(*func(*T, T))(unsafe.Pointer(&f))
 	case []float32:
-		f := func(c1 *[]float32, c2 []float32) {
-			for i, v := range c2 {
-				(*c1)[i] += v
-			}
-		}
+		f := addSlice[float32]
 		c.Add = *(*func(*T, T))(unsafe.Pointer(&f))
 	case []float64:
-		f := func(c1 *[]float64, c2 []float64) {
-			for i, v := range c2 {
-				(*c1)[i] += v
-			}
-		}
+		f := addSlice[float64]
 		c.Add = *(*func(*T, T))(unsafe.Pointer(&f))
 	default:
 		panic(fmt.Errorf("could not automatically set the implementation for Add because type %T is not a supported type", t))
 	}
 }
+
+// addSlice adds c2 to c1 elementwise growing c1 if needed.
+func addSlice[E int | int64 | float32 | float64](c1 *[]E, c2 []E) {
+	if n := len(c2) - len(*c1); n > 0 {
+		*c1 = append(*c1, make([]E, n)...)
+	}
+	for i, v := range c2 {
+		(*c1)[i] += v
+	}
+}
@@ -1,10 +1,13 @@
 package mcts
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand"
 	"plugin"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,6 +16,12 @@ import (
 // In practice, ExploreFactor is a tunable hyperparameter.
 const DefaultExploreFactor = math.Sqrt2
 
+// DefaultWideningExponent is the default exponent for progressive widening.
+const DefaultWideningExponent = 0.5
+
+// DefaultVirtualLoss is a loss of 1 per in-flight worker assuming scores normalized to the interval [-1, +1].
+const DefaultVirtualLoss = 1
+
 // Search contains options used to run the MCTS Search.
 //
 // It also maintains a continuation which supports repeated calls to Search
@@ -27,10 +36,57 @@ type Search[T Counter] struct {
 	// Optional counter implementation.
 	CounterInterface[T]
 
+	// RootEntry is the root of the search continuation.
+	//
+	// RootEntry is set by Init and cleared by Reset.
+	RootEntry *EdgeList[T]
+
 	// NumEpisodes ends the Search after the given fixed number
-	// of episodes. Default is 100.
+	// of episodes. Default is 100, or no limit when TimeBudget, Deadline,
+	// or a context deadline is set so that the Search runs until the time limit.
+	//
+	// NumEpisodes < 0 places no limit on the number of episodes.
+	// The Search then runs until another limit is reached.
 	NumEpisodes int
 
+	// TimeBudget ends the Search after the given duration.
+	// Zero places no time limit on the Search.
+	TimeBudget time.Duration
+
+	// Deadline ends the Search at the given time.
+	// The zero Time places no deadline on the Search.
+	Deadline time.Time
+
+	// MaxNodes ends the Search once the search structure has at least MaxNodes Nodes.
+	// Zero places no limit on the number of Nodes.
+	MaxNodes int
+
+	// NodeBudget bounds the number of Nodes in the search structure.
+	//
+	// When the search structure exceeds NodeBudget Nodes, subtrees with the fewest rollouts
+	// are pruned until 3/4 of the budget remains. Pruned Nodes keep the statistics of their
+	// parent Edge and will be expanded again if selected. Unlike MaxNodes, the Search
+	// continues after pruning.
+	// Zero places no budget on the number of Nodes.
+	NodeBudget int
+
+	// ByteBudget bounds the approximate memory used by the search structure in bytes.
+	//
+	// ByteBudget is converted to a NodeBudget using the fixed size of an Edge. Memory referenced
+	// by counters, Actions, or the SearchInterface is not accounted for.
+	// Zero places no budget on memory.
+	ByteBudget int64
+
+	// EarlyStopInterval checks the root every EarlyStopInterval episodes and ends the
+	// Search once the most visited root Action can no longer be overtaken within the
+	// remaining NumEpisodes or time budget.
+	//
+	// Early stopping is skipped while Actions may still be added to the root, as with
+	// progressive widening before the root is fully expanded, Determinize, TopoOpenLoop,
+	// or a simultaneous move root.
+	// Zero disables early stopping.
+	EarlyStopInterval int
+
 	// Seed provides repeatable randomness to the search.
 	// By default Seed is set to the current UNIX timestamp nanos.
 	Seed int64
@@ -50,14 +106,157 @@ type Search[T Counter] struct {
 	// This should be made roughly proportional to scores obtained from random rollouts.
 	// Zero uses the default value of DefaultExploreFactor.
 	ExploreFactor float64
+
+	// SelectionPolicy computes the value of selecting each child.
+	// Nil uses the default PUCB policy.
+	SelectionPolicy SelectionPolicy
+
+	// SelectTemperature samples children from a softmax over selection values
+	// instead of always selecting the best child.
+	//
+	//	P(child) ∝ exp(Value(child) / SelectTemperature).
+	//
+	// Unvisited children are still selected first.
+	// SelectTemperature is ignored when SelectionPolicy is a SamplingPolicy.
+	// Zero always selects the best child.
+	SelectTemperature float64
+
+	// FirstPlayUrgency sets the value of unvisited children during selection.
+	//
+	// Unvisited children are scored as a single rollout with the urgency value
+	// so they compete with visited children by the SelectionPolicy including
+	// their prior weight.
+	// Nil visits every child before revisiting any.
+	FirstPlayUrgency FirstPlayUrgency
+
+	// WideningFactor and WideningExponent enable progressive widening for large
+	// or continuous action spaces.
+	//
+	// A node visited N times may have at most
+	//
+	//	ceil(WideningFactor * N^WideningExponent)
+	//
+	// children. SearchInterface.ExpandRanked is required and is called with this limit
+	// when a node is first expanded and called again with the larger limit as visits
+	// accumulate. Actions already in the search structure are ignored and prior weights
+	// are renormalized as new children arrive. A node is fully expanded once ExpandRanked
+	// returns fewer actions than the limit.
+	//
+	// Zero WideningFactor expands all actions at once.
+	// Zero WideningExponent uses the default value of DefaultWideningExponent.
+	WideningFactor   float64
+	WideningExponent float64
+
+	// RolloutDepth is the maximum number of Actions played by the default rollout policy
+	// before RolloutInterface.Evaluate is called on the resulting state.
+	//
+	// RolloutDepth is only used with Evaluate. Zero evaluates leaves without a rollout.
+	RolloutDepth int
+
+	// Solver enables MCTS-Solver semantics.
+	//
+	// Terminal states, where Expand returns no actions, are marked with a Proof using
+	// MakeProof on the terminal score. Proofs propagate up the search structure and
+	// proven Edges are no longer searched below: proven losses are never selected and
+	// rollouts through proven Edges return the proven score. The Search stops with
+	// StopProven when the outcome of the root is proven.
+	//
+	// Solver requires deterministic terminal scores and zero-sum objectives.
+	// With SearchInterface.Determinize or TopoOpenLoop, only terminal states are proven.
+	Solver bool
+
+	// ScoreBounds extends Solver with score-bounded search.
+	//
+	// Each Edge keeps Pessimistic and Optimistic bounds on its value which are tightened
+	// as children are solved. Children whose Optimistic bound is below the Pessimistic
+	// bound of a sibling are never selected. Rather than stopping on the first proven win,
+	// the Search stops with StopProven once a root child is proven to be best,
+	// such as the fastest win when scores decrease with depth.
+	//
+	// ScoreBounds assumes the objective of each Edge is either the objective of its
+	// children or its negation, as with the objectives in the model package.
+	// ScoreBounds implies Solver.
+	ScoreBounds bool
+
+	// Reduction values the choices of each player in N-player games such as MaxN or Paranoid.
+	//
+	// With a Reduction, the objective of each Edge is chosen from PlayerObjectives by the
+	// acting player from SearchInterface.Player rather than by the Objective returned by Score.
+	// The root player is the acting player at the root when Search is called.
+	// Under Paranoid and BestReply, AdvanceRoot discards the search structure when the root player changes
+	// since its Edges are valued against the old root player.
+	// Default is ReductionNone.
+	Reduction Reduction
+
+	// PlayerObjectives is the objective of each player used by Reduction.
+	//
+	// See model.PlayerComponents for objectives which maximize each component of the counters.
+	PlayerObjectives []func(T) float64
+
+	// JointPolicy chooses the Action of each player at simultaneous move nodes.
+	// See SearchInterface.Simultaneous.
+	// Nil uses DecoupledUCT.
+	JointPolicy JointPolicy
+
+	// NumWorkers runs episodes concurrently on the shared search structure.
+	//
+	// Each worker uses its own SearchInterface from SearchInterface.Clone and its own Rand
+	// seeded from Rand. Selection, expansion, and backprop are serialized while rollouts
+	// run concurrently. NumWorkers is most effective when rollouts dominate search time.
+	// Default is 1.
+	NumWorkers int
+
+	// VirtualLoss is the score penalty applied for each worker searching through an Edge
+	// when NumWorkers > 1, and for each pending leaf when BatchSize > 1.
+	// VirtualLoss keeps workers and batches from selecting the same variation.
+	//
+	// This should be made roughly proportional to scores obtained from random rollouts.
+	// Zero uses the default value of DefaultVirtualLoss.
+	VirtualLoss float64
+
+	// BatchEvaluator evaluates leaves in batches in place of rollouts,
+	// as with a value and policy network in AlphaZero.
+	//
+	// Each worker selects and expands up to BatchSize leaves, applying VirtualLoss to the
+	// Edges of pending leaves so that the batch explores different variations. The leaves are
+	// then passed to EvaluateBatch together and the values and prior weights it returns are
+	// backpropagated. As with RolloutInterface.Evaluate, each node is evaluated when it is
+	// first expanded. Terminal and proven leaves are scored immediately and are not passed
+	// to the BatchEvaluator. SearchInterface.Snapshot is required to pass the state of each leaf.
+	//
+	// With NumWorkers > 1, each worker evaluates its batches concurrently.
+	// BatchEvaluator takes precedence over RolloutInterface.
+	BatchEvaluator BatchEvaluator[T]
+
+	// BatchSize is the maximum number of leaves passed to the BatchEvaluator at once.
+	// Default is 1.
+	BatchSize int
 }
 
 func (s *Search[T]) patchDefaults() {
 	if s.ExploreFactor == 0 {
 		s.ExploreFactor = DefaultExploreFactor
 	}
-	if s.NumEpisodes == 0 {
-		s.NumEpisodes = 100
+	if s.ScoreBounds {
+		s.Solver = true
+	}
+	if s.WideningExponent == 0 {
+		s.WideningExponent = DefaultWideningExponent
+	}
+	if s.SelectionPolicy == nil {
+		s.SelectionPolicy = PUCB{}
+	}
+	if s.JointPolicy == nil {
+		s.JointPolicy = DecoupledUCT{}
+	}
+	if s.NumWorkers == 0 {
+		s.NumWorkers = 1
+	}
+	if s.BatchSize == 0 {
+		s.BatchSize = 1
+	}
+	if s.VirtualLoss == 0 {
+		s.VirtualLoss = DefaultVirtualLoss
 	}
 	if s.Rand == nil {
 		if s.Seed == 0 {
@@ -91,40 +290,239 @@ func (s *Search[T]) LoadPlugin(path string) error {
 // Init additionally patches default parameter values.
 func (s *Search[T]) Init() bool {
 	s.patchDefaults()
-	s.InternalInterface.Init(s)
 	if s.SearchInterface.Root == nil {
 		panic("Search.Init: Search.SearchInterface.Root is nil. A search implementation is required before calling Search or Init.")
 	}
+	if s.InternalInterface.Init == nil {
+		panic("Search.Init: Search.InternalInterface is not set. Use model.MakeSearchInterface to select a builtin topology.")
+	}
+	if s.Reduction != ReductionNone {
+		if s.SearchInterface.Player == nil {
+			panic("Search.Init: Search.SearchInterface.Player is nil. Player is required when Reduction is set.")
+		}
+		if len(s.PlayerObjectives) == 0 {
+			panic("Search.Init: Search.PlayerObjectives is empty. PlayerObjectives are required when Reduction is set.")
+		}
+	}
+	if s.Reduction == BestReply {
+		if s.SearchInterface.SetPlayer == nil {
+			panic("Search.Init: Search.SearchInterface.SetPlayer is nil. SetPlayer is required with BestReply.")
+		}
+		if s.Determinize != nil || s.Topo == TopoOpenLoop {
+			panic("Search.Init: BestReply is not supported with Determinize or TopoOpenLoop.")
+		}
+	}
+	if s.WideningFactor > 0 && s.SearchInterface.ExpandRanked == nil {
+		panic("Search.Init: Search.SearchInterface.ExpandRanked is nil. ExpandRanked is required with WideningFactor.")
+	}
+	if s.BatchEvaluator != nil && s.InternalInterface.Leaf == nil {
+		panic("Search.Init: Search.InternalInterface.Leaf is nil. Leaf is required with BatchEvaluator.")
+	}
+	if s.BatchEvaluator != nil && s.SearchInterface.Snapshot == nil {
+		panic("Search.Init: Search.SearchInterface.Snapshot is nil. Snapshot is required with BatchEvaluator.")
+	}
+	s.InternalInterface.Init(s)
 	return true
 }
 
 // Reset deletes the search continuation and RNG so the next call to Search starts from scratch.
+//
+// The memory of the previous search structure may be reused by the next Search,
+// so Edges and EdgeLists must not be retained after Reset.
 func (s *Search[T]) Reset() {
-	s.InternalInterface.Reset(s)
+	if s.InternalInterface.Reset != nil {
+		s.InternalInterface.Reset(s)
+	}
+	s.RootEntry = nil
 	s.Rand = nil
 }
 
-// Search runs the search NumEpisodes times.
-func (s *Search[T]) Search() {
+// AdvanceRoot re-roots the search continuation at the state reached by applying actions
+// from the current root, keeping the statistics of the subtree.
+//
+// AdvanceRoot is usually called after a move is played. SearchInterface.Root must already
+// reset to the new root state when AdvanceRoot is called. Nodes which are no longer reachable
+// from the new root are dropped from the search structure. The remaining Edges may be moved
+// so Edges and EdgeLists obtained before AdvanceRoot must not be used after it.
+//
+// AdvanceRoot returns false if the new root was not in the search structure or the
+// search structure was discarded, in which case the search continues from an empty root.
+// See Reduction.
+func (s *Search[T]) AdvanceRoot(actions ...Action) bool {
+	if s.RootEntry == nil {
+		// No continuation to keep.
+		// The new root will be created by Init.
+		return false
+	}
+	if s.InternalInterface.AdvanceRoot == nil {
+		panic("Search.AdvanceRoot: Search.InternalInterface.AdvanceRoot is nil.")
+	}
+	return s.InternalInterface.AdvanceRoot(s, actions)
+}
+
+// JointStats returns the statistics of each player at the simultaneous move node n
+// or nil if n is not a simultaneous move node.
+//
+// PlayerStats.Strategy gives the recommended mixed strategy for each player.
+// JointStats must not be called while the Search is running.
+func (s *Search[T]) JointStats(n *EdgeList[T]) []PlayerStats[T] {
+	if s.InternalInterface.JointStats == nil {
+		return nil
+	}
+	return s.InternalInterface.JointStats(n)
+}
+
+// Search runs the search NumEpisodes times or until another limit is reached.
+func (s *Search[T]) Search() SearchResult {
+	return s.SearchContext(context.Background())
+}
+
+// SearchContext runs the search until NumEpisodes are completed, ctx is done,
+// or the TimeBudget, Deadline, or MaxNodes limits are reached.
+//
+// The returned SearchResult reports which limit ended the search.
+func (s *Search[T]) SearchContext(ctx context.Context) SearchResult {
+	start := time.Now()
 	s.Init()
-	for i := 0; i < s.NumEpisodes; i++ {
-		s.searchEpisode()
+	limits := s.makeLimits(ctx, start)
+	var res SearchResult
+	if s.NumWorkers > 1 {
+		res = s.searchParallel(limits)
+	} else {
+		res = s.searchSerial(limits)
 	}
+	limits.prune()
+	res.SavedEpisodes = limits.savedEpisodes
+	res.NumPruned = limits.numPruned
+	res.Elapsed = time.Since(start)
+	return res
 }
 
-func (s *Search[T]) searchEpisode() {
-	s.InternalInterface.Root()
-	s.SearchInterface.Root() // Reset to root.
+func (s *Search[T]) searchSerial(limits *searchLimits[T]) SearchResult {
+	var b *batch[T]
+	if s.BatchEvaluator != nil {
+		b = s.newBatch(s.SearchInterface)
+	}
+	var res SearchResult
+	for limits.maxEpisodes < 0 || res.NumEpisodes < limits.maxEpisodes {
+		limits.prune()
+		if reason, stop := limits.check(res.NumEpisodes); stop {
+			res.StopReason = reason
+			return res
+		}
+		if b != nil {
+			k := s.batchLen(res.NumEpisodes, limits.maxEpisodes)
+			s.searchBatch(b, k, s.Rand, nil)
+			res.NumEpisodes += k
+			continue
+		}
+		s.searchEpisode(s.SearchInterface, s.Rand, nil)
+		res.NumEpisodes++
+	}
+	res.StopReason = StopEpisodes
+	return res
+}
+
+// searchParallel runs episodes using NumWorkers concurrent workers.
+func (s *Search[T]) searchParallel(limits *searchLimits[T]) SearchResult {
+	if s.SearchInterface.Clone == nil {
+		panic("Search.Search: Search.SearchInterface.Clone is nil. Clone is required when NumWorkers > 1.")
+	}
+	if s.InternalInterface.Fork == nil {
+		panic("Search.Search: Search.InternalInterface.Fork is nil. Fork is required when NumWorkers > 1.")
+	}
+	var (
+		mu          sync.Mutex
+		wg          sync.WaitGroup
+		numEpisodes atomic.Int64 // Number of episodes claimed by workers.
+		completed   atomic.Int64 // Number of episodes completed by workers.
+		stopOnce    sync.Once
+		res         = SearchResult{StopReason: StopEpisodes}
+	)
+	for i := 0; i < s.NumWorkers; i++ {
+		si := s.SearchInterface.Clone()
+		// Topo may have been changed after Clone was set.
+		si.Topo = s.Topo
+		si.InternalInterface = s.InternalInterface.Fork(&si)
+		r := rand.New(rand.NewSource(s.Rand.Int63()))
+		var b *batch[T]
+		if s.BatchEvaluator != nil {
+			b = s.newBatch(si)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				limits.prune()
+				reason, stop := limits.check(int(completed.Load()))
+				mu.Unlock()
+				if stop {
+					stopOnce.Do(func() { res.StopReason = reason })
+					return
+				}
+				k := 1
+				if b != nil {
+					k = s.BatchSize
+				}
+				if n := numEpisodes.Add(int64(k)); limits.maxEpisodes >= 0 && n > int64(limits.maxEpisodes) {
+					// Claim only the remaining episodes.
+					if k -= int(n - int64(limits.maxEpisodes)); k <= 0 {
+						return
+					}
+				}
+				if b != nil {
+					s.searchBatch(b, k, r, &mu)
+				} else {
+					s.searchEpisode(si, r, &mu)
+				}
+				completed.Add(int64(k))
+			}
+		}()
+	}
+	wg.Wait()
+	res.NumEpisodes = int(completed.Load())
+	return res
+}
+
+// searchEpisode runs a single episode using the given SearchInterface and Rand.
+//
+// If mu is not nil, it is held for all operations on the search structure
+// and released during the rollout.
+func (s *Search[T]) searchEpisode(si SearchInterface[T], r *rand.Rand, mu *sync.Mutex) {
+	if mu != nil {
+		mu.Lock()
+	}
+	s.selectLeaf(si, r)
+	if mu != nil {
+		mu.Unlock()
+	}
+	// Simulate and backprop score.
+	counters, numRollouts := si.InternalInterface.Rollout(si, si.RolloutInterface, r)
+	if mu != nil {
+		// Backprop is always called to release in-flight edges.
+		mu.Lock()
+		defer mu.Unlock()
+	} else if numRollouts == 0 {
+		return
+	}
+	si.Backprop(s.CounterInterface, counters, numRollouts, s.ExploreFactor)
+}
+
+// selectLeaf runs the selection and expansion phases of an episode from the root.
+func (s *Search[T]) selectLeaf(si SearchInterface[T], r *rand.Rand) {
+	si.InternalInterface.Root()
+	si.Root() // Reset to root.
+	if si.Determinize != nil {
+		// Sample the hidden information for this episode.
+		si.Determinize(r)
+	}
 	// Select the best leaf node by MAB policy.
 	var doExpand bool
-	for hasChild := true; hasChild; hasChild, doExpand = s.SelectChild(s.SearchInterface) {
+	for hasChild := true; hasChild; hasChild, doExpand = si.SelectChild(si, r) {
 	}
 	// Expand a new frontier node.
 	if doExpand {
-		s.InternalInterface.Expand(s.SearchInterface, s.Rand)
-	}
-	// Simulate and backprop score.
-	if counters, numRollouts := s.InternalInterface.Rollout(s.SearchInterface, s.RolloutInterface, s.Rand); numRollouts != 0 {
-		s.Backprop(s.CounterInterface, counters, numRollouts, s.ExploreFactor)
+		si.InternalInterface.Expand(si, r)
 	}
 }
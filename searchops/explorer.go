@@ -0,0 +1,51 @@
+package searchops
+
+import "github.com/wenooij/mcts"
+
+// edgeListExplorer implements Explorer for the builtin EdgeList search structure.
+type edgeListExplorer[T mcts.Counter] struct{ n *mcts.EdgeList[T] }
+
+// NewExplorer returns an Explorer starting at the given EdgeList.
+//
+// NewExplorer is usually called with Search.RootEntry.
+func NewExplorer[T mcts.Counter](root *mcts.EdgeList[T]) Explorer[T] {
+	return &edgeListExplorer[T]{root}
+}
+
+func (x *edgeListExplorer[T]) Walk(walkFn func(mcts.Node[T]) error) error {
+	if x.n == nil {
+		return nil
+	}
+	for _, e := range *x.n {
+		if err := walkFn(e.Node); err != nil {
+			if err == ErrStopIteration {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *edgeListExplorer[T]) Select(a mcts.Action) bool {
+	if x.n == nil {
+		return false
+	}
+	e := Child(x.n, a)
+	if e == nil {
+		return false
+	}
+	x.n = e.Dst
+	return true
+}
+
+func (x *edgeListExplorer[T]) Len() int {
+	if x.n == nil {
+		return 0
+	}
+	return len(*x.n)
+}
+
+func (x *edgeListExplorer[T]) At(i int) mcts.Node[T] { return (*x.n)[i].Node }
+
+func (x *edgeListExplorer[T]) Ptr() any { return x.n }
@@ -47,10 +47,13 @@ func ValueNodeReducer[T mcts.Counter, E comparable](r func(E, E) E) func(res, b
 
 func ValueNodesReducer[T mcts.Counter, E comparable](r func(E, E) E) func(res, b ValueNodes[T, E]) ValueNodes[T, E] {
 	return func(res, b ValueNodes[T, E]) ValueNodes[T, E] {
-		rv := r(res.Value, b.Value)
-		if rv == res.Value {
+		switch rv := r(res.Value, b.Value); {
+		case b.Value == res.Value:
 			return ValueNodes[T, E]{rv, append(res.Nodes, b.Nodes...)}
+		case rv == res.Value:
+			return res
+		default:
+			return b
 		}
-		return b
 	}
 }
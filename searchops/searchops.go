@@ -3,6 +3,7 @@ package searchops
 
 import (
 	"errors"
+	"reflect"
 
 	"github.com/wenooij/mcts"
 )
@@ -30,15 +31,27 @@ func Children[T mcts.Counter](e Explorer[T]) []mcts.Node[T] {
 
 // Child searches the immediate children of n one-by-one and returns the subtree for a
 // Otherwise returns nil if a is not present.
+//
+// Actions which are not comparable, such as JointAction, are matched by String.
 func Child[T mcts.Counter](n *mcts.EdgeList[T], a mcts.Action) *mcts.Edge[T] {
 	for _, e := range *n {
-		if e.Action == a {
+		if sameAction(e.Action, a) {
 			return e
 		}
 	}
 	return nil
 }
 
+// sameAction returns true if x and y are the same Action.
+//
+// Actions of a type which is not comparable, such as JointAction, are compared by String.
+func sameAction(x, y mcts.Action) bool {
+	if t := reflect.TypeOf(x); t == reflect.TypeOf(y) && t != nil && !t.Comparable() {
+		return x.String() == y.String()
+	}
+	return x == y
+}
+
 // Subtree returns a the subtree defined by the input actions.
 //
 // If not all actions are present, Subtree returns nil.
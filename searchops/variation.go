@@ -24,6 +24,7 @@ func StripRoot[T any](vs []mcts.Node[T]) []mcts.Node[T] {
 // The first element in the Variation may be a root node.
 // It will have a nil Action as well among other differences.
 // Use NodeType.Root to check or Variation.TrimRoot to trim it.
+// Chance outcomes are shown with their Probability.
 func FormatVariation[T any](vs []mcts.Node[T]) (s string) {
 	var sb strings.Builder
 	if len(vs) > 0 && vs[0].Score.Objective != nil {
@@ -33,13 +34,23 @@ func FormatVariation[T any](vs []mcts.Node[T]) (s string) {
 		sb.WriteString("[???]")
 	}
 	for _, v := range vs {
+		if v.Probability > 0 {
+			// Show the probability of chance outcomes.
+			fmt.Fprintf(&sb, " %s(p=%.2f)", v.Action.String(), v.Probability)
+			continue
+		}
 		fmt.Fprintf(&sb, " %s", v.Action.String())
 	}
 	fmt.Fprintf(&sb, " (%d)", int64(vs[0].NumRollouts))
+	if vs[0].Proof != mcts.Unproven {
+		fmt.Fprintf(&sb, " %s", vs[0].ProofString())
+	}
 	return sb.String()
 }
 
 // PrincipalVariation returns the main variation for this Search.
+//
+// The shortest proven win is always preferred over the most visited node.
 func PrincipalVariation[T mcts.Counter](ex Explorer[T], r *rand.Rand, lastFilter LastFilter) []mcts.Node[T] {
 	out := []mcts.Node[T]{}
 	for {
@@ -47,11 +58,16 @@ func PrincipalVariation[T mcts.Counter](ex Explorer[T], r *rand.Rand, lastFilter
 		if n == 0 {
 			return out
 		}
+		if node, ok := provenWin(ex); ok {
+			out = append(out, node)
+			ex.Select(node.Action)
+			continue
+		}
 		maxNodes, err := Reduce(
-			ValueNodesMapper[T, MinMax](MinMaxMapper(RolloutsMapper[T]())),
-			ValueNodes[T, MinMax]{MinMax{math.Inf(+1), math.Inf(-1)}, nil},
+			ValueNodesMapper[T, float64](RolloutsMapper[T]()),
+			ValueNodes[T, float64]{math.Inf(-1), nil},
 			ex,
-			ValueNodesReducer[T, MinMax](MinMaxReducer(RolloutsMapper[T]())))
+			ValueNodesReducer[T, float64](math.Max))
 		if err != nil || len(maxNodes.Nodes) == 0 {
 			return out
 		}
@@ -60,8 +76,20 @@ func PrincipalVariation[T mcts.Counter](ex Explorer[T], r *rand.Rand, lastFilter
 			return out
 		}
 		out = append(out, node)
-		ex.Select(node)
+		ex.Select(node.Action)
+	}
+}
+
+// provenWin returns the child of ex with the shortest proven win if any.
+func provenWin[T mcts.Counter](ex Explorer[T]) (mcts.Node[T], bool) {
+	var win mcts.Node[T]
+	var ok bool
+	for i := range ex.Len() {
+		if node := ex.At(i); node.Proof == mcts.ProvenWin && (!ok || node.ProofDepth < win.ProofDepth) {
+			win, ok = node, true
+		}
 	}
+	return win, ok
 }
 
 // RandomVariation returns a uniform random variation with runs for this Search.
@@ -81,7 +109,7 @@ func UniformRandomVariation[T mcts.Counter](ex Explorer[T], r *rand.Rand, visitF
 			}
 			return err
 		}
-		ex.Select(node)
+		ex.Select(node.Action)
 	}
 }
 
@@ -102,6 +130,6 @@ func WeightedRandomVariation[T mcts.Counter](ex Explorer[T], r *rand.Rand, visit
 			}
 			return err
 		}
-		ex.Select(node)
+		ex.Select(node.Action)
 	}
 }
@@ -0,0 +1,229 @@
+package mcts
+
+import (
+	"context"
+	"math"
+	"time"
+	"unsafe"
+)
+
+// StopReason describes the limit which ended a Search.
+type StopReason int
+
+const (
+	StopEpisodes StopReason = iota // StopEpisodes indicates NumEpisodes were completed.
+	StopCanceled                   // StopCanceled indicates the context was canceled.
+	StopDeadline                   // StopDeadline indicates the Deadline, TimeBudget, or context deadline was reached.
+	StopMaxNodes                   // StopMaxNodes indicates the search structure reached MaxNodes.
+	StopEarly                      // StopEarly indicates the most visited root Action could no longer change.
+	StopProven                     // StopProven indicates the outcome of the root was proven with Solver.
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case StopEpisodes:
+		return "episodes"
+	case StopCanceled:
+		return "canceled"
+	case StopDeadline:
+		return "deadline"
+	case StopMaxNodes:
+		return "max nodes"
+	case StopEarly:
+		return "early"
+	case StopProven:
+		return "proven"
+	default:
+		return "unknown"
+	}
+}
+
+// SearchResult summarizes a call to Search.
+type SearchResult struct {
+	// StopReason is the limit which ended the Search.
+	StopReason StopReason
+
+	// NumEpisodes is the number of episodes completed.
+	NumEpisodes int
+
+	// SavedEpisodes is the estimated number of episodes saved when StopReason is StopEarly.
+	SavedEpisodes int
+
+	// NumPruned is the number of Nodes pruned to satisfy NodeBudget or ByteBudget.
+	NumPruned int
+
+	// Elapsed is the time spent in Search.
+	Elapsed time.Duration
+}
+
+// searchLimits checks the limits of a single call to Search.
+type searchLimits[T Counter] struct {
+	ctx         context.Context
+	start       time.Time
+	deadline    time.Time
+	maxEpisodes int
+	maxNodes    int
+	numNodes    func() int
+	solved      func() bool
+
+	// Pruning state.
+	nodeBudget int
+	pruneFunc  func(maxNodes int) int
+	numPruned  int
+
+	// Early stopping state.
+	root              *EdgeList[T]
+	rootComplete      func() bool
+	earlyStopInterval int
+	lastEarlyStop     int
+	startRollouts     float64
+	savedEpisodes     int
+}
+
+func (s *Search[T]) makeLimits(ctx context.Context, start time.Time) *searchLimits[T] {
+	l := &searchLimits[T]{
+		ctx:               ctx,
+		start:             start,
+		deadline:          s.Deadline,
+		maxEpisodes:       s.NumEpisodes,
+		maxNodes:          s.MaxNodes,
+		numNodes:          s.InternalInterface.NumNodes,
+		nodeBudget:        s.NodeBudget,
+		pruneFunc:         s.InternalInterface.Prune,
+		root:              s.RootEntry,
+		rootComplete:      s.InternalInterface.RootComplete,
+		earlyStopInterval: s.EarlyStopInterval,
+	}
+	if l.maxEpisodes == 0 {
+		l.maxEpisodes = 100
+		if _, ok := ctx.Deadline(); ok || s.TimeBudget > 0 || !s.Deadline.IsZero() {
+			// Run until the time limit rather than the default number of episodes.
+			l.maxEpisodes = -1
+		}
+	}
+	if s.ByteBudget > 0 {
+		nodeBytes := int64(unsafe.Sizeof(Edge[T]{}) + unsafe.Sizeof((*Edge[T])(nil)))
+		if n := int(s.ByteBudget / nodeBytes); l.nodeBudget == 0 || n < l.nodeBudget {
+			l.nodeBudget = max(n, 1)
+		}
+	}
+	if s.TimeBudget > 0 {
+		if d := start.Add(s.TimeBudget); l.deadline.IsZero() || d.Before(l.deadline) {
+			l.deadline = d
+		}
+	}
+	if d, ok := ctx.Deadline(); ok && (l.deadline.IsZero() || d.Before(l.deadline)) {
+		l.deadline = d
+	}
+	if s.Solver {
+		l.solved = s.InternalInterface.Solved
+	}
+	if l.numNodes == nil {
+		l.maxNodes = 0
+	}
+	if l.numNodes == nil || l.pruneFunc == nil {
+		l.nodeBudget = 0
+	}
+	if l.earlyStopInterval > 0 {
+		l.startRollouts, _, _ = rootRollouts(l.root)
+	}
+	return l
+}
+
+// prune prunes the search structure when it exceeds the node budget.
+func (l *searchLimits[T]) prune() {
+	if l.nodeBudget > 0 && l.numNodes() > l.nodeBudget {
+		l.numPruned += l.pruneFunc(3 * l.nodeBudget / 4)
+	}
+}
+
+// check returns a StopReason and true if any limit besides NumEpisodes was reached.
+//
+// numEpisodes is the number of episodes completed so far.
+func (l *searchLimits[T]) check(numEpisodes int) (StopReason, bool) {
+	select {
+	case <-l.ctx.Done():
+		if l.ctx.Err() == context.DeadlineExceeded {
+			return StopDeadline, true
+		}
+		return StopCanceled, true
+	default:
+	}
+	if !l.deadline.IsZero() && !time.Now().Before(l.deadline) {
+		return StopDeadline, true
+	}
+	if l.maxNodes > 0 && l.numNodes() >= l.maxNodes {
+		return StopMaxNodes, true
+	}
+	if l.solved != nil && l.solved() {
+		return StopProven, true
+	}
+	if l.earlyStopInterval > 0 && numEpisodes-l.lastEarlyStop >= l.earlyStopInterval {
+		l.lastEarlyStop = numEpisodes
+		if saved, ok := l.checkEarlyStop(numEpisodes); ok {
+			l.savedEpisodes = saved
+			return StopEarly, true
+		}
+	}
+	return 0, false
+}
+
+// checkEarlyStop returns the number of remaining episodes and true if the most visited
+// root Action cannot be overtaken within the remaining episodes.
+func (l *searchLimits[T]) checkEarlyStop(numEpisodes int) (int, bool) {
+	remaining, ok := l.remainingEpisodes(numEpisodes)
+	if !ok {
+		return 0, false
+	}
+	if l.rootComplete != nil && !l.rootComplete() {
+		// A new root Action may still be added and overtake the others.
+		return 0, false
+	}
+	if len(*l.root) == 1 {
+		// A single root Action can never be overtaken.
+		return remaining, true
+	}
+	total, first, second := rootRollouts(l.root)
+	rolloutsPerEpisode := 1.0
+	if numEpisodes > 0 && total > l.startRollouts {
+		rolloutsPerEpisode = (total - l.startRollouts) / float64(numEpisodes)
+	}
+	if first-second <= float64(remaining)*rolloutsPerEpisode {
+		return 0, false
+	}
+	return remaining, true
+}
+
+// remainingEpisodes estimates the number of episodes remaining in the episode and time budgets.
+//
+// The time budget estimate is based on the rate of episodes completed so far.
+// remainingEpisodes returns false if the Search has no episode or time budget.
+func (l *searchLimits[T]) remainingEpisodes(numEpisodes int) (int, bool) {
+	remaining, ok := math.MaxInt, false
+	if l.maxEpisodes >= 0 {
+		remaining, ok = l.maxEpisodes-numEpisodes, true
+	}
+	if !l.deadline.IsZero() && numEpisodes > 0 {
+		elapsed, left := time.Since(l.start), time.Until(l.deadline)
+		if n := int(math.Ceil(float64(numEpisodes) * float64(left) / float64(elapsed))); n < remaining {
+			remaining = n
+		}
+		ok = true
+	}
+	return max(remaining, 0), ok
+}
+
+// rootRollouts returns the total rollouts of root edges as well as the
+// rollouts of the most and second most visited edges.
+func rootRollouts[T Counter](root *EdgeList[T]) (total, first, second float64) {
+	for _, e := range *root {
+		total += e.NumRollouts
+		switch n := e.NumRollouts; {
+		case n > first:
+			first, second = n, first
+		case n > second:
+			second = n
+		}
+	}
+	return total, first, second
+}